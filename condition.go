@@ -0,0 +1,225 @@
+package dbselector
+
+import (
+	"fmt"
+	"strings"
+)
+
+/*Condition - условие для секций WHERE, ON и HAVING: простое сравнение поля
+или группа условий. Условия создаются функциями Eq, Gt, In, Like, Not, AnyOf, AllOf
+и т.п., могут вкладываться друг в друга произвольным образом и передаются
+в WhereExpr, AndExpr и OrExpr.
+*/
+type Condition interface {
+	conditionSql(s *Selector, raw bool, binds map[string]interface{}) (string, error)
+}
+
+// элемент группы условий вместе с логической связкой, которая ставится перед ним
+type conditionItem struct {
+	conjunction string // AND или OR, для вложенной группы определяется ее первым условием
+	cond        Condition
+}
+
+// группа условий, которая при вложении заключается в скобки
+type conditionGroup struct {
+	items []conditionItem
+}
+
+/*Дерево условий секции WHERE, ON или HAVING. Дерево строится по мере вызова
+методов Where/And/Or и OpenBracket/CloseBracket: скобки открывают и закрывают
+вложенные группы, а первое условие дерева всегда идет сразу после ключевого слова,
+каким бы методом оно ни было добавлено.
+*/
+type conditionTree struct {
+	root   conditionGroup
+	opened []*conditionGroup // стек открытых скобок
+	err    error             // ошибка построения дерева (лишняя закрывающая скобка)
+}
+
+//добавляет условие в текущую группу дерева
+func (t *conditionTree) add(conjunction string, cond Condition) {
+	group := t.current()
+	group.items = append(group.items, conditionItem{conjunction: conjunction, cond: cond})
+}
+
+//открывает вложенную группу условий
+func (t *conditionTree) open() {
+	group := &conditionGroup{}
+	t.add("", group)
+	t.opened = append(t.opened, group)
+}
+
+//закрывает последнюю открытую группу условий
+func (t *conditionTree) close() {
+	if len(t.opened) == 0 {
+		if t.err == nil {
+			t.err = ErrUnbalancedBrackets
+		}
+		return
+	}
+	t.opened = t.opened[:len(t.opened)-1]
+}
+
+//возвращает группу, в которую добавляются условия
+func (t *conditionTree) current() *conditionGroup {
+	if len(t.opened) > 0 {
+		return t.opened[len(t.opened)-1]
+	}
+	return &t.root
+}
+
+//возвращает копию дерева, не разделяющую с исходным деревом группы условий
+func (t *conditionTree) clone() conditionTree {
+	groups := map[*conditionGroup]*conditionGroup{}
+	c := conditionTree{root: t.root.clone(groups), err: t.err}
+	for _, group := range t.opened {
+		c.opened = append(c.opened, groups[group])
+	}
+	return c
+}
+
+//возвращает копию группы, groups сопоставляет вложенным группам их копии
+func (g *conditionGroup) clone(groups map[*conditionGroup]*conditionGroup) conditionGroup {
+	c := conditionGroup{items: make([]conditionItem, len(g.items))}
+	for i, item := range g.items {
+		if group, ok := item.cond.(*conditionGroup); ok {
+			nested := group.clone(groups)
+			groups[group] = &nested
+			item.cond = &nested
+		}
+		c.items[i] = item
+	}
+	return c
+}
+
+//возвращает true, если в дереве нет ни одного условия
+func (t *conditionTree) empty() bool {
+	return t.root.empty()
+}
+
+//формирует секцию с условиями, keyword (WHERE, ON, HAVING) ставится перед первым условием
+func (t *conditionTree) sql(keyword string, s *Selector, raw bool, binds map[string]interface{}) (string, error) {
+	if t.err != nil {
+		return "", t.err
+	}
+	if len(t.opened) > 0 {
+		return "", ErrUnbalancedBrackets
+	}
+
+	sql, err := t.root.itemsSql(s, raw, binds)
+	if err != nil || sql == "" {
+		return "", err
+	}
+	return " " + keyword + " " + sql, nil
+}
+
+//возвращает true, если в группе и вложенных в нее группах нет ни одного условия
+func (g *conditionGroup) empty() bool {
+	for _, item := range g.items {
+		if group, ok := item.cond.(*conditionGroup); !ok || !group.empty() {
+			return false
+		}
+	}
+	return true
+}
+
+//возвращает связку первого условия группы, она используется перед самой группой
+func (g *conditionGroup) conjunction() string {
+	for _, item := range g.items {
+		if group, ok := item.cond.(*conditionGroup); ok {
+			if group.empty() {
+				continue
+			}
+			if item.conjunction == "" {
+				return group.conjunction()
+			}
+		}
+		return item.conjunction
+	}
+	return conjunctionAnd
+}
+
+//возвращает true, если условия группы соединены хотя бы одной связкой OR
+func (g *conditionGroup) hasOr() bool {
+	for i, item := range g.items {
+		if i == 0 {
+			continue
+		}
+		conjunction := item.conjunction
+		if group, ok := item.cond.(*conditionGroup); ok && conjunction == "" {
+			conjunction = group.conjunction()
+		}
+		if conjunction == conjunctionOr {
+			return true
+		}
+	}
+	return false
+}
+
+//формирует условия группы, соединенные логическими связками, без внешних скобок
+func (g *conditionGroup) itemsSql(s *Selector, raw bool, binds map[string]interface{}) (string, error) {
+	parts := make([]string, 0, len(g.items))
+	for _, item := range g.items {
+		sql, err := item.cond.conditionSql(s, raw, binds)
+		if err != nil {
+			return "", err
+		}
+		if sql == "" {
+			continue
+		}
+
+		if len(parts) > 0 {
+			conjunction := item.conjunction
+			if group, ok := item.cond.(*conditionGroup); ok && conjunction == "" {
+				conjunction = group.conjunction()
+			}
+			parts = append(parts, conjunction)
+		}
+		parts = append(parts, sql)
+	}
+	return strings.Join(parts, " "), nil
+}
+
+func (g *conditionGroup) conditionSql(s *Selector, raw bool, binds map[string]interface{}) (string, error) {
+	sql, err := g.itemsSql(s, raw, binds)
+	if err != nil || sql == "" {
+		return "", err
+	}
+	return "(" + sql + ")", nil
+}
+
+func (c clause) conditionSql(s *Selector, raw bool, binds map[string]interface{}) (string, error) {
+	op, err := s.operator(c.operation)
+	if err != nil {
+		return "", err
+	}
+	field, err := s.conditionField(c.field)
+	if err != nil {
+		return "", err
+	}
+	ph, err := s.bindValue(c.field, c.bind, raw, binds)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%v %v %v", field, op, ph), nil
+}
+
+func (c inClause) conditionSql(s *Selector, raw bool, binds map[string]interface{}) (string, error) {
+	field, err := s.conditionField(c.field)
+	if err != nil {
+		return "", err
+	}
+	bindNames := s.getBindingNamesIN(c.field, raw, len(c.binds))
+	for i := range c.binds {
+		binds[bindNames[i]] = c.binds[i]
+	}
+	return fmt.Sprintf("%v IN %v", field, s.getPlaceholdersIN(bindNames, raw)), nil
+}
+
+func (c trueClause) conditionSql(s *Selector, raw bool, binds map[string]interface{}) (string, error) {
+	return s.dialect.boolLiteral(true), nil
+}
+
+func (c falseClause) conditionSql(s *Selector, raw bool, binds map[string]interface{}) (string, error) {
+	return s.dialect.boolLiteral(false), nil
+}
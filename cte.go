@@ -0,0 +1,106 @@
+package dbselector
+
+import (
+	"fmt"
+	"strings"
+)
+
+// общее табличное выражение секции WITH
+type cte struct {
+	name      string    //имя выражения, по которому к нему обращается запрос
+	query     *Selector //запрос выражения, для рекурсивного - начальная часть
+	recursive *Selector //рекурсивная часть, nil для обычного выражения
+}
+
+/*Добавляет к запросу общее табличное выражение WITH name AS (query), к которому
+запрос может обращаться как к таблице. Выражения формируются в порядке добавления,
+их параметры нумеруются перед параметрами самого запроса.
+Параметры:
+	name - имя выражения
+	query - запрос SELECT
+Результат:
+	ссылка Selector на самого себя
+Пример использования:
+	active := &Selector{}
+	active.Select("user").Where("active", "=", true)
+	selector := &Selector{}
+	selector.With("active_user", active).Select("active_user").Where("age", ">", 18)
+	тогда sql содержит: WITH "active_user" AS (SELECT * FROM "user" WHERE "active" = :active1)
+		SELECT * FROM "active_user" WHERE "age" > :age2
+*/
+func (s *Selector) With(name string, query *Selector) *Selector {
+	s.ctes = append(s.ctes, cte{name: name, query: query})
+	return s
+}
+
+/*Добавляет к запросу рекурсивное общее табличное выражение
+WITH RECURSIVE name AS (anchor UNION ALL recursive). Рекурсивная часть
+обращается к выражению по его имени как к таблице.
+Параметры:
+	name - имя выражения
+	anchor - начальная часть выражения
+	recursive - рекурсивная часть выражения
+Результат:
+	ссылка Selector на самого себя
+Пример использования:
+	root := &Selector{}
+	root.Select("category").Columns("id", "parent_id").Where("id", "=", 7)
+	children := &Selector{}
+	children.Select("category").As("c").Columns("c.id", "c.parent_id").Join("tree", "t").On("c.parent_id", "=", Ident("t.id"))
+	selector := &Selector{}
+	selector.WithRecursive("tree", root, children).Select("tree")
+	тогда sql содержит: WITH RECURSIVE "tree" AS (SELECT "id", "parent_id" FROM "category" WHERE "id" = :id1
+		UNION ALL SELECT "c"."id", "c"."parent_id" FROM "category" AS "c" INNER JOIN "tree" AS "t" ON "c"."parent_id" = "t"."id")
+		SELECT * FROM "tree"
+*/
+func (s *Selector) WithRecursive(name string, anchor *Selector, recursive *Selector) *Selector {
+	if recursive == nil {
+		s.setError(ErrNotSelect)
+		return s
+	}
+	s.ctes = append(s.ctes, cte{name: name, query: anchor, recursive: recursive})
+	return s
+}
+
+//формирует секцию WITH и биндинг
+func (s *Selector) withSql(raw bool) (string, map[string]interface{}, error) {
+	binds := make(map[string]interface{})
+	if len(s.ctes) == 0 {
+		return "", binds, nil
+	}
+	if s.operation == QUERY_INSERT && s.dialect == DIALECT_MYSQL {
+		return "", binds, fmt.Errorf("%w: WITH перед INSERT в %v", ErrUnsupported, s.dialect)
+	}
+
+	keyword := "WITH "
+	parts := make([]string, 0, len(s.ctes))
+	for _, c := range s.ctes {
+		name, err := s.identifier(c.name)
+		if err != nil {
+			return "", binds, err
+		}
+		query, err := s.cteQuerySql(c.query, raw, binds)
+		if err != nil {
+			return "", binds, err
+		}
+		if c.recursive != nil {
+			keyword = "WITH RECURSIVE "
+			recursive, err := s.cteQuerySql(c.recursive, raw, binds)
+			if err != nil {
+				return "", binds, err
+			}
+			query += " UNION ALL " + recursive
+		}
+		parts = append(parts, name+" AS ("+query+")")
+	}
+	return keyword + strings.Join(parts, ", ") + " ", binds, nil
+}
+
+//формирует запрос общего табличного выражения и добавляет его параметры в биндинг
+func (s *Selector) cteQuerySql(query *Selector, raw bool, binds map[string]interface{}) (string, error) {
+	sql, queryBinds, err := s.subquerySql(query, raw)
+	for k, v := range queryBinds {
+		binds[k] = v
+	}
+	return sql, err
+}
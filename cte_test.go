@@ -0,0 +1,65 @@
+package dbselector
+
+import "testing"
+
+func TestWith(t *testing.T) {
+
+	active := &Selector{}
+	active.Select("user").Where("active", "=", true)
+
+	sel := &Selector{}
+	sel.With("active_user", active).Select("active_user").Where("age", ">", 18)
+	sql, binds, err := sel.Build()
+	compareError(t, nil, err)
+
+	gageSql := "WITH \"active_user\" AS (SELECT * FROM \"user\" WHERE \"active\" = :active1) " +
+		"SELECT * FROM \"active_user\" WHERE \"age\" > :age2"
+	compareSql(t, gageSql, sql)
+	compareBinds(t, binds, map[string]interface{}{"active1": true, "age2": 18})
+
+	banned := &Selector{}
+	banned.Select("ban").Columns("user_id").Where("until", ">", "2021-01-01")
+
+	sel = &Selector{}
+	sel.With("active_user", active).With("banned", banned).
+		Update("user").Set("active", false).WhereInSelector("id", (&Selector{}).Select("banned").Columns("user_id"))
+	rawSql, rawBinds, err := sel.BuildRaw()
+	compareError(t, nil, err)
+
+	gageSql = "WITH \"active_user\" AS (SELECT * FROM \"user\" WHERE \"active\" = $1), " +
+		"\"banned\" AS (SELECT \"user_id\" FROM \"ban\" WHERE \"until\" > $2) " +
+		"UPDATE \"user\" SET \"active\" = $3 WHERE \"id\" IN (SELECT \"user_id\" FROM \"banned\")"
+	compareSql(t, gageSql, rawSql)
+	compareBinds(t, rawBinds, []interface{}{true, "2021-01-01", false})
+}
+
+func TestWithRecursive(t *testing.T) {
+
+	root := &Selector{}
+	root.Select("category").Columns("id", "parent_id").Where("id", "=", 7)
+	children := &Selector{}
+	children.Select("category").As("c").Columns("c.id", "c.parent_id").
+		Join("tree", "t").On("c.parent_id", "=", Ident("t.id")).Where("c.hidden", "=", false)
+
+	sel := NewSelector(DIALECT_MYSQL)
+	sel.WithRecursive("tree", root, children).Delete("category").WhereInSelector("id", (&Selector{}).Select("tree").Columns("id"))
+	sql, binds, err := sel.BuildRaw()
+	compareError(t, nil, err)
+
+	gageSql := "WITH RECURSIVE `tree` AS (SELECT `id`, `parent_id` FROM `category` WHERE `id` = ? " +
+		"UNION ALL SELECT `c`.`id`, `c`.`parent_id` FROM `category` AS `c` INNER JOIN `tree` AS `t` " +
+		"ON `c`.`parent_id` = `t`.`id` WHERE `c`.`hidden` = ?) " +
+		"DELETE FROM `category` WHERE `id` IN (SELECT `id` FROM `tree`)"
+	compareSql(t, gageSql, sql)
+	compareBinds(t, binds, []interface{}{7, false})
+
+	sel = NewSelector(DIALECT_MYSQL)
+	sel.WithRecursive("tree", root, children).Insert("archive").From((&Selector{}).Select("tree"))
+	_, _, err = sel.BuildRaw()
+	compareError(t, ErrUnsupported, err)
+
+	sel = &Selector{}
+	sel.WithRecursive("tree", root, nil).Select("tree")
+	_, _, err = sel.BuildRaw()
+	compareError(t, ErrNotSelect, err)
+}
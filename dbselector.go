@@ -1,10 +1,10 @@
 package dbselector
 
 import (
-	"errors"
 	"fmt"
 	"reflect"
 	"strings"
+	"unicode"
 )
 
 //Basic type that describes the condition in section WHERE of SQL-query
@@ -23,9 +23,13 @@ type inClause struct {
 // True-clause - used in WhereIn
 type trueClause struct{}
 
+// False-clause - used in In with empty array
+type falseClause struct{}
+
 type order struct {
 	field string
 	dir   string
+	nulls string // положение NULL: "nulls first", "nulls last" или пустая строка
 }
 
 type setItem struct {
@@ -33,22 +37,31 @@ type setItem struct {
 	bind  interface{}
 }
 
-type (
-	whereClause     clause
-	andClause       clause
-	orClause        clause
-	whereInClause   inClause
-	andInClause     inClause
-	orInClause      inClause
-	whereTrueClause trueClause
+// элемент списка выбираемых полей запроса SELECT
+type column struct {
+	field  string  // имя поля, заключается в кавычки, для оконной функции - ее вызов
+	expr   string  // произвольное выражение, вставляется в запрос как есть
+	alias  string  // псевдоним поля
+	window *Window // окно оконной функции
+}
 
-	bracket bool // true - open bracket, false - close bracket
+// логические связки условий
+const (
+	conjunctionAnd = "AND"
+	conjunctionOr  = "OR"
 )
 
+/*Ident - ссылка на поле таблицы, которую можно передать вместо значения в условие.
+Такое значение не попадает в биндинг, а вставляется в запрос как идентификатор.
+Пример использования:
+	selector.Join("post", "p").On("p.user_id", "=", Ident("u.id"))
+*/
+type Ident string
+
 type SqlDialect int
 
 const (
-	DIALECT_POSTGRESS = iota
+	DIALECT_POSTGRESS SqlDialect = iota
 	DIALECT_MYSQL
 	DIALECT_SQLITE
 )
@@ -69,26 +82,43 @@ const (
 	selector.Select("user").Where("name","=","Дима").Or("email","LIKE","%fulleren.io")
 	selector.OrderBy("name DESC").Limit(5)
 	sql, binds := selector.Sql()
-Замечание: при задании условий вызов Where должен идти в первую очередь, т.е.
-можно написать selector.Where(...).Or(...).And(...)
-но нельзя писать так selector.Or(...).Where(...).And(...). Возможно в следующей версии это
-ограничение будет снято.
+Замечание: условия можно задавать в любом порядке, первое из них всегда попадает в секцию WHERE,
+поэтому selector.Or(...).Where(...).And(...) равносильно selector.Where(...).Or(...).And(...).
+Where в середине цепочки работает как And.
 */
 type Selector struct {
-	operation        SqlQueryType  //операция SELECT, DELETE, UPDATE
-	tableName        string        //имя таблицы
-	orderBy          string        //порядок сортировки
-	orders           []order       //список полей для сортировки
-	limit            int           //максимальное количество записей, возвращаемых запросом
-	offset           int           //смещение при выборке результатов
-	count            bool          //указание на подсчет количества элементов в результате
-	clauses          []interface{} //список правил для секции WHERE
-	parameterPrefix  string        //префикс для названий подставляемых параметров
-	parameterCounter int           //счетчик обработанных параметров
-	returning        string        //имена полей, возвращаемых при INSERT через запятую
-	values           []interface{} //структуры данных для INSERT запроса
+	operation        SqlQueryType    //операция SELECT, DELETE, UPDATE
+	tableName        string          //имя таблицы
+	alias            string          //псевдоним таблицы
+	joins            []join          //список присоединяемых таблиц
+	groupBy          []string        //список полей для группировки
+	having           conditionTree   //дерево условий секции HAVING
+	orderBy          string          //порядок сортировки
+	orders           []order         //список полей для сортировки
+	sortable         map[string]bool //поля, по которым допускается сортировка через OrderBind
+	limit            int             //максимальное количество записей, возвращаемых запросом
+	offset           int             //смещение при выборке результатов
+	count            bool            //указание на подсчет количества элементов в результате
+	columns          []column        //список выбираемых полей, если пуст - выбираются все (*)
+	distinct         bool            //указание на выборку только уникальных строк
+	distinctOn       []string        //список полей для DISTINCT ON
+	where            conditionTree   //дерево условий секции WHERE
+	parameterPrefix  string          //префикс для названий подставляемых параметров
+	parameterCounter int             //счетчик обработанных параметров
+	returning        []string        //имена полей, возвращаемых запросом в секции RETURNING
+	values           []interface{}   //структуры данных для INSERT запроса
 	sets             []setItem
 	dialect          SqlDialect
+	explicitKeys     bool           //включать в INSERT автоматически генерируемые ключи
+	conflict         *onConflict    //обработка конфликта уникальности в INSERT
+	source           *Selector      //запрос SELECT, строки которого вставляет INSERT
+	fromQuery        *Selector      //вложенный запрос секции FROM вместо таблицы для подсчета строк
+	ctes             []cte          //общие табличные выражения секции WITH
+	setOperations    []setOperation //запросы, объединяемые с основным через UNION, INTERSECT, EXCEPT
+	windows          []namedWindow  //именованные окна секции WINDOW
+	lock             *rowLock       //блокировка выбранных строк
+	keyset           *keyset        //позиция постраничной выборки по ключу
+	err              error          //первая ошибка, допущенная при построении запроса
 }
 
 //Устанавливает префикс для имен подставлемых в запрос параметров
@@ -141,7 +171,7 @@ func (s *Selector) Insert(tableName string) *Selector {
 	selector.Where("active","=","true")
 */
 func (s *Selector) Where(field string, operation string, bind interface{}) *Selector {
-	s.clauses = append(s.clauses, whereClause{field, operation, bind})
+	s.where.add(conjunctionAnd, clause{field, operation, bind})
 	return s
 }
 
@@ -158,9 +188,9 @@ func (s *Selector) Where(field string, operation string, bind interface{}) *Sele
 
 func (s *Selector) WhereIn(field string, binds []interface{}) *Selector {
 	if len(binds) > 0 {
-		s.clauses = append(s.clauses, whereInClause{field, binds})
+		s.where.add(conjunctionAnd, inClause{field, binds})
 	} else {
-		s.clauses = append(s.clauses, whereTrueClause{})
+		s.where.add(conjunctionAnd, trueClause{})
 	}
 	return s
 }
@@ -178,7 +208,7 @@ func (s *Selector) WhereIn(field string, binds []interface{}) *Selector {
 	selector.And("active", "=", "true")
 */
 func (s *Selector) And(field string, operation string, bind interface{}) *Selector {
-	s.clauses = append(s.clauses, andClause{field, operation, bind})
+	s.where.add(conjunctionAnd, clause{field, operation, bind})
 	return s
 }
 
@@ -195,7 +225,7 @@ func (s *Selector) And(field string, operation string, bind interface{}) *Select
 */
 func (s *Selector) AndIn(field string, binds []interface{}) *Selector {
 	if len(binds) > 0 {
-		s.clauses = append(s.clauses, andInClause{field, binds})
+		s.where.add(conjunctionAnd, inClause{field, binds})
 	}
 	return s
 }
@@ -213,7 +243,7 @@ func (s *Selector) AndIn(field string, binds []interface{}) *Selector {
 	selector.Or("active", "=", "true")
 */
 func (s *Selector) Or(field string, operation string, bind interface{}) *Selector {
-	s.clauses = append(s.clauses, orClause{field, operation, bind})
+	s.where.add(conjunctionOr, clause{field, operation, bind})
 	return s
 }
 
@@ -230,7 +260,7 @@ func (s *Selector) Or(field string, operation string, bind interface{}) *Selecto
 */
 func (s *Selector) OrIn(field string, binds []interface{}) *Selector {
 	if len(binds) > 0 {
-		s.clauses = append(s.clauses, orInClause{field, binds})
+		s.where.add(conjunctionOr, inClause{field, binds})
 	}
 	return s
 }
@@ -245,7 +275,7 @@ func (s *Selector) OrIn(field string, binds []interface{}) *Selector {
 	selector.Where(...)
 */
 func (s *Selector) OpenBracket() *Selector {
-	s.clauses = append(s.clauses, bracket(true))
+	s.where.open()
 	return s
 }
 
@@ -261,7 +291,7 @@ func (s *Selector) OpenBracket() *Selector {
 	selector.CloseBracket()
 */
 func (s *Selector) CloseBracket() *Selector {
-	s.clauses = append(s.clauses, bracket(false))
+	s.where.close()
 	return s
 }
 
@@ -281,9 +311,87 @@ func (s *Selector) Set(field string, bind interface{}) *Selector {
 	return s
 }
 
+/*Задает список выбираемых запросом SELECT полей вместо *
+Имена полей заключаются в кавычки в соответствии с диалектом, допускается
+указание таблицы через точку и звездочки: "u.*"
+Параметры:
+	fields - имена полей в таблице БД
+Результат:
+	ссылка Selector на самого себя
+Пример использования:
+	selector := &Selector{}
+	selector.Select("user").Columns("id", "name", "email")
+	тогда sql содержит: SELECT "id", "name", "email" FROM "user"
+*/
+func (s *Selector) Columns(fields ...string) *Selector {
+	for _, field := range fields {
+		s.columns = append(s.columns, column{field: field})
+	}
+	return s
+}
+
+/*Добавляет к списку выбираемых полей поле с псевдонимом
+Параметры:
+	field - имя поля в таблице БД
+	alias - псевдоним, под которым поле будет возвращено
+Результат:
+	ссылка Selector на самого себя
+Пример использования:
+	selector := &Selector{}
+	selector.Select("user").ColumnAs("name", "user_name")
+	тогда sql содержит: SELECT "name" AS "user_name" FROM "user"
+*/
+func (s *Selector) ColumnAs(field string, alias string) *Selector {
+	s.columns = append(s.columns, column{field: field, alias: alias})
+	return s
+}
+
+/*Добавляет к списку выбираемых полей произвольное выражение.
+Выражение вставляется в запрос без изменений, поэтому в нем нельзя
+использовать данные, полученные от пользователя.
+Параметры:
+	expr - sql-выражение
+	alias - псевдоним выражения, если пустая строка - псевдоним не указывается
+Результат:
+	ссылка Selector на самого себя
+Пример использования:
+	selector := &Selector{}
+	selector.Select("user").ColumnExpr("lower(email)", "email")
+	тогда sql содержит: SELECT lower(email) AS "email" FROM "user"
+*/
+func (s *Selector) ColumnExpr(expr string, alias string) *Selector {
+	s.columns = append(s.columns, column{expr: expr, alias: alias})
+	return s
+}
+
+/*
+	Сообщает селектору, что запрос SELECT должен вернуть
+	только уникальные строки (SELECT DISTINCT).
+*/
+func (s *Selector) Distinct() *Selector {
+	s.distinct = true
+	return s
+}
+
+/*Задает список полей для секции DISTINCT ON (только PostgreSQL)
+Параметры:
+	fields - имена полей, по которым определяется уникальность строки
+Результат:
+	ссылка Selector на самого себя
+Пример использования:
+	selector := &Selector{}
+	selector.Select("post").DistinctOn("author_id").Columns("author_id", "title")
+	тогда sql содержит: SELECT DISTINCT ON ("author_id") "author_id", "title" FROM "post"
+*/
+func (s *Selector) DistinctOn(fields ...string) *Selector {
+	s.distinctOn = append(s.distinctOn, fields...)
+	return s
+}
+
 /*Задает порядок сортировки
 Параметры:
-	order - строка вставляемая в секцию ORDER BY
+	order - строка вставляемая в секцию ORDER BY без изменений, поэтому имена полей,
+		полученные от пользователя, нужно передавать через OrderBind
 Результат:
 	ссылка Selector на самого себя
 Пример использования:
@@ -296,33 +404,78 @@ func (s *Selector) OrderBy(order string) *Selector {
 }
 
 /*Задает порядок сортировки с безопасной подстановкой имен полей в секцию ORDER BY.
+Имя поля проверяется и заключается в кавычки, а если задан список SortableColumns,
+то поле должно входить в этот список.
 Если сортировка задана функцией selector.OrderBy("order_string"), то сортировка будет идти по
 строке order_string с игнорированием параметров переданных через selector.OrderBind()
 Параметры:
 	field - имя поля для сортировки
 	dir - направление сортировки. Допустимы значения ASC и DESC, если передано другое то
-			будет использовано ASC
+			будет использовано ASC. После направления можно указать NULLS FIRST или NULLS LAST
+			(не поддерживается в MySQL), направление при этом можно опустить: "NULLS FIRST".
+			Некорректное указание NULLS приводит к ошибке ErrInvalidOrder
 Результат:
 	ссылка Selector на самого себя
 Пример использования:
 	selector := &Selector{}
-	selector.OrderBind("email","ASC").OrderBind("email","DESC")
+	selector.OrderBind("email","ASC").OrderBind("rating","DESC NULLS LAST")
+	тогда sql содержит: ... ORDER BY "email" asc, "rating" desc nulls last
 */
 func (s *Selector) OrderBind(field string, dir string) *Selector {
-	dir = strings.ToLower(dir)
-	if dir != "asc" && dir != "desc" {
-		dir = "asc"
+	o, err := newOrder(field, dir)
+	if err != nil {
+		s.setError(err)
+		return s
 	}
+	s.orders = append(s.orders, o)
+	return s
+}
 
-	s.orders = append(s.orders, order{field: field, dir: dir})
-
+/*Задает список полей, по которым допускается сортировка через OrderBind.
+Сортировка по полю не из списка приводит к ошибке ErrColumnNotAllowed.
+Параметры:
+	fields - имена полей в том виде, в котором они передаются в OrderBind
+Результат:
+	ссылка Selector на самого себя
+Пример использования:
+	selector := &Selector{}
+	selector.Select("user").SortableColumns("name", "email").OrderBind(r.FormValue("sort"), "ASC")
+*/
+func (s *Selector) SortableColumns(fields ...string) *Selector {
+	if s.sortable == nil {
+		s.sortable = map[string]bool{}
+	}
+	for _, field := range fields {
+		s.sortable[field] = true
+	}
 	return s
 }
 
-/*Задает имена полей, возвращаемых запросом INSERT
+//разбирает направление сортировки вида "desc nulls last", направление можно не указывать:
+//"nulls first" равносильно "asc nulls first", неизвестное направление заменяется на asc
+func newOrder(field string, dir string) (order, error) {
+	o := order{field: field, dir: "asc"}
+	words := strings.Fields(strings.ToLower(dir))
+	if len(words) > 0 && words[0] != "nulls" {
+		if words[0] == "desc" {
+			o.dir = "desc"
+		}
+		words = words[1:]
+	}
+	switch {
+	case len(words) == 0:
+	case len(words) == 2 && words[0] == "nulls" && (words[1] == "first" || words[1] == "last"):
+		o.nulls = "nulls " + words[1]
+	default:
+		return o, fmt.Errorf("%w: %q", ErrInvalidOrder, dir)
+	}
+	return o, nil
+}
+
+/*Задает имена полей, возвращаемых запросом INSERT, UPDATE или DELETE
 Параметры:
-	fields - значение, вставляемое в секцию RETURNING
-	это должны быть имена полей через запятую
+	fields - имена полей для секции RETURNING, допускается
+	передавать несколько имен в одной строке через запятую
 Результат:
 	ссылка Selector на самого себя
 Пример использования:
@@ -333,10 +486,9 @@ func (s *Selector) OrderBind(field string, dir string) *Selector {
 
 */
 func (s *Selector) Returning(fields ...string) *Selector {
-	for i, field := range fields {
-		s.returning += field
-		if i < len(fields)-1 {
-			s.returning += ","
+	for _, field := range fields {
+		for _, name := range strings.Split(field, ",") {
+			s.returning = append(s.returning, strings.TrimSpace(name))
 		}
 	}
 	return s
@@ -372,46 +524,104 @@ func (s *Selector) Offset(offset int) *Selector {
 
 /*
 	Сообщает селектору, что sql-запрос должен возвратить
-	количество найденный элементов.
+	количество найденный элементов. Запрос с DISTINCT, DISTINCT ON или объединением
+	оборачивается во внешний SELECT count(*) FROM (...).
 */
 func (s *Selector) Count() *Selector {
 	s.count = true
 	return s
 }
 
+/*Включает в запрос INSERT значения полей, отмеченных как автоматически генерируемый
+ключ (опция auto в теге db или поле id без опций). По умолчанию такие поля пропускаются
+и значение ключа назначает БД.
+Результат:
+	ссылка Selector на самого себя
+Пример использования:
+	selector := &Selector{}
+	selector.Insert("user").Values(users).ExplicitKeys()
+*/
+func (s *Selector) ExplicitKeys() *Selector {
+	s.explicitKeys = true
+	return s
+}
+
 /* Добавляет к sql запросу VALUES _значения_
 Параметры:
-	data - данные для подстановки
+	data - данные для подстановки: структура, указатель на структуру,
+	словарь map[string]interface{} или срез из них ([]T, []*T, []interface{})
 Результат:
 	ссылка Selector на самого себя
 Пример использования:
 	selector := &Selector{}
 	selector.Insert("user").Values(users)
+	selector.Insert("user").Values(map[string]interface{}{"name": "Vova", "age": 30})
 */
-func (s *Selector) Values(data []interface{}) *Selector {
-	for _, element := range data {
-		s.values = append(s.values, element)
+func (s *Selector) Values(data interface{}) *Selector {
+	v := reflect.ValueOf(data)
+	if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+		s.values = append(s.values, data)
+		return s
+	}
+	for i := 0; i < v.Len(); i++ {
+		s.values = append(s.values, v.Index(i).Interface())
 	}
 	return s
 }
 
 /* Формирует параметризированный SQL-запрос и словарь данных для параметризации
 Результат:
-	1. строка с sql-запросом
+	1. строка с sql-запросом, пустая при ошибке (причину возвращает Build)
 	2. словарь с данными для параметризации
 Пример использования:
 	selector := &Selector{}
 	selector.Select("user").Where("name","=","Вася").Or("age","<","18").OrderBy("name DESC").Limit(5)
 	sql, binds := selector.Sql()
-	тогда sql содержит: SELECT * FROM "user" WHERE name = :name OR age < :age ORDER BY name DESC LIMIT 5
-	binds содержит: {"name":"Вася","age":"18"}
+	тогда sql содержит: SELECT * FROM "user" WHERE "name" = :name1 OR "age" < :age2 ORDER BY name DESC LIMIT 5
+	binds содержит: {"name1":"Вася","age2":"18"}
 */
 func (s *Selector) Sql() (string, map[string]interface{}) {
-	return s.sql(false)
+	sql, binds, _ := s.Build()
+	return sql, binds
 }
 
 func (s *Selector) RawSql() (string, []interface{}) {
-	sql, binds := s.sql(true)
+	sql, binds, _ := s.BuildRaw()
+	return sql, binds
+}
+
+/* Формирует параметризированный SQL-запрос и словарь данных для параметризации так же,
+как Sql, но дополнительно проверяет корректность запроса.
+Результат:
+	1. строка с sql-запросом
+	2. словарь с данными для параметризации
+	3. ошибка или nil, в случае ошибки запрос не возвращается
+Пример использования:
+	selector := &Selector{}
+	selector.Update("user").Where("id", "=", 7)
+	sql, binds, err := selector.Build()
+	тогда err содержит ErrNoSet
+*/
+func (s *Selector) Build() (string, map[string]interface{}, error) {
+	sql, binds, err := s.sql(false)
+	if err != nil {
+		return "", map[string]interface{}{}, err
+	}
+	return sql, binds, nil
+}
+
+/* Формирует SQL-запрос с позиционными параметрами и срез данных для параметризации
+так же, как RawSql, но дополнительно проверяет корректность запроса.
+Результат:
+	1. строка с sql-запросом
+	2. срез с данными для параметризации
+	3. ошибка или nil, в случае ошибки запрос не возвращается
+*/
+func (s *Selector) BuildRaw() (string, []interface{}, error) {
+	sql, binds, err := s.sql(true)
+	if err != nil {
+		return "", []interface{}{}, err
+	}
 
 	resultBinds := make([]interface{}, 0, len(binds))
 	for i := 1; i <= len(binds); i++ {
@@ -421,11 +631,40 @@ func (s *Selector) RawSql() (string, []interface{}) {
 		}
 	}
 
-	return sql, resultBinds
+	return sql, resultBinds, nil
 }
 
-func (s *Selector) sql(raw bool) (string, map[string]interface{}) {
+func (s *Selector) sql(raw bool) (string, map[string]interface{}, error) {
 	s.parameterCounter = 0
+	return s.render(raw)
+}
+
+//формирует запрос без сброса счетчика параметров, что позволяет продолжить
+//нумерацию параметров внешнего запроса во вложенном
+func (s *Selector) render(raw bool) (string, map[string]interface{}, error) {
+	if s.err != nil {
+		return "", map[string]interface{}{}, s.err
+	}
+	if s.tableName == "" && s.fromQuery == nil {
+		return "", map[string]interface{}{}, ErrNoTable
+	}
+
+	withSql, binds, err := s.withSql(raw)
+	if err != nil {
+		return "", binds, err
+	}
+	sql, statementBinds, err := s.statementSql(raw)
+	if err != nil {
+		return "", binds, err
+	}
+	for k, v := range statementBinds {
+		binds[k] = v
+	}
+	return withSql + sql, binds, nil
+}
+
+//формирует сам запрос SELECT, DELETE, UPDATE или INSERT без секции WITH
+func (s *Selector) statementSql(raw bool) (string, map[string]interface{}, error) {
 	switch s.operation {
 	case QUERY_SELECT:
 		return s.selectSql(raw)
@@ -444,23 +683,38 @@ func (s *Selector) sql(raw bool) (string, map[string]interface{}) {
 func (s *Selector) getBindingName(param string, raw bool) string {
 	s.parameterCounter++
 	if !raw {
-		return fmt.Sprintf("%v%v%d", s.parameterPrefix, param, s.parameterCounter)
+		return fmt.Sprintf("%v%v%d", s.parameterPrefix, bindingBaseName(param), s.parameterCounter)
 	}
 
 	return fmt.Sprintf("$%d", s.parameterCounter)
 }
 
+//приводит имя поля к виду, допустимому в имени параметра: группы символов кроме букв,
+//цифр и подчеркивания заменяются на одно подчеркивание (u.id -> u_id, sum(amount) -> sum_amount)
+func bindingBaseName(param string) string {
+	res := make([]rune, 0, len(param))
+	separator := false
+	for _, r := range param {
+		if r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if separator && len(res) > 0 {
+				res = append(res, '_')
+			}
+			res = append(res, r)
+			separator = false
+		} else {
+			separator = true
+		}
+	}
+	return string(res)
+}
+
 //возвращает заместитель для псевдонимов в sql-запросе
 func (s *Selector) getPlaceholder(bindName string, raw bool) string {
 	if !raw {
 		return ":" + bindName
 	}
 
-	if s.dialect == DIALECT_POSTGRESS {
-		return bindName
-	}
-
-	return "?"
+	return s.dialect.placeholder(bindName)
 }
 
 // служебный метод возвращающий имена параметров для подстановки для сравнения IN
@@ -475,7 +729,7 @@ func (s *Selector) getBindingNamesIN(param string, raw bool, count int) []string
 	for i := 0; i < count; i++ {
 		s.parameterCounter++
 		if !raw {
-			res = append(res, fmt.Sprintf("%v%v%d", s.parameterPrefix, param, s.parameterCounter))
+			res = append(res, fmt.Sprintf("%v%v%d", s.parameterPrefix, bindingBaseName(param), s.parameterCounter))
 		} else {
 			res = append(res, fmt.Sprintf("$%d", s.parameterCounter))
 		}
@@ -492,13 +746,7 @@ func (s *Selector) getPlaceholdersIN(bindNames []string, raw bool) string {
 	res = "("
 
 	for i, p := range bindNames {
-		if !raw {
-			res += fmt.Sprintf(":%s", p)
-		} else if s.dialect == DIALECT_POSTGRESS {
-			res += p
-		} else {
-			res += "?"
-		}
+		res += s.getPlaceholder(p, raw)
 
 		if i < len(bindNames)-1 {
 			res += ","
@@ -510,309 +758,332 @@ func (s *Selector) getPlaceholdersIN(bindNames []string, raw bool) string {
 }
 
 //формирует запрос вида DELETE FROM table WHERE ...
-func (s *Selector) deleteSql(raw bool) (string, map[string]interface{}) {
-	resultSql := fmt.Sprintf("DELETE FROM \"%v\"", s.tableName)
-	whereSql, binds := s.whereSql(raw)
+func (s *Selector) deleteSql(raw bool) (string, map[string]interface{}, error) {
+	tableName, err := s.identifier(s.tableName)
+	if err != nil {
+		return "", map[string]interface{}{}, err
+	}
+	resultSql := fmt.Sprintf("DELETE FROM %v", tableName)
+	whereSql, binds, err := s.whereSql(raw)
+	if err != nil {
+		return "", binds, err
+	}
 	resultSql += whereSql
 
-	if s.returning != "" {
-		resultSql += " RETURNING " + s.returning
+	returningSql, err := s.returningSql()
+	if err != nil {
+		return "", binds, err
 	}
+	resultSql += returningSql
 
-	return resultSql, binds
+	return resultSql, binds, nil
 }
 
 //формирует запрос UPDATE
-func (s *Selector) updateSql(raw bool) (string, map[string]interface{}) {
-	resultSql := fmt.Sprintf("UPDATE \"%v\" SET", s.tableName)
+func (s *Selector) updateSql(raw bool) (string, map[string]interface{}, error) {
 	binds := map[string]interface{}{}
+	tableName, err := s.identifier(s.tableName)
+	if err != nil {
+		return "", binds, err
+	}
+	resultSql := fmt.Sprintf("UPDATE %v SET", tableName)
+	if len(s.sets) == 0 {
+		return "", binds, ErrNoSet
+	}
 
 	for i, si := range s.sets {
-		bindName := s.getBindingName(si.field, raw)
-		ph := s.getPlaceholder(bindName, raw)
+		field, err := s.identifier(si.field)
+		if err != nil {
+			return "", binds, err
+		}
 		if i != 0 {
 			resultSql += ","
 		}
-		resultSql += fmt.Sprintf(" %v = %v", si.field, ph)
+		if _, ok := si.bind.(defaultValue); ok {
+			if s.dialect == DIALECT_SQLITE {
+				return "", binds, fmt.Errorf("%w: DEFAULT в SET в %v", ErrUnsupported, s.dialect)
+			}
+			resultSql += fmt.Sprintf(" %v = DEFAULT", field)
+			continue
+		}
+		bindName := s.getBindingName(si.field, raw)
+		ph := s.getPlaceholder(bindName, raw)
+		resultSql += fmt.Sprintf(" %v = %v", field, ph)
 		binds[bindName] = si.bind
 	}
 
-	whereSql, whereBind := s.whereSql(raw)
+	whereSql, whereBind, err := s.whereSql(raw)
+	if err != nil {
+		return "", binds, err
+	}
 	resultSql += whereSql
 
-	if s.returning != "" {
-		resultSql += " RETURNING " + s.returning
+	returningSql, err := s.returningSql()
+	if err != nil {
+		return "", binds, err
 	}
+	resultSql += returningSql
 
 	for k, v := range whereBind {
 		binds[k] = v
 	}
 
-	return resultSql, binds
+	return resultSql, binds, nil
 }
 
 //формирует запрос типа INSERT INTO ... VALUES ...
-func (s *Selector) insertSql(raw bool) (string, map[string]interface{}) {
-	resultSQL := fmt.Sprintf("INSERT INTO \"%s\"", s.tableName)
-	valuesSql, binds := s.valuesSql(raw)
+func (s *Selector) insertSql(raw bool) (string, map[string]interface{}, error) {
+	tableName, err := s.identifier(s.tableName)
+	if err != nil {
+		return "", map[string]interface{}{}, err
+	}
+	resultSQL := fmt.Sprintf("INSERT INTO %s", tableName)
+	var valuesSql string
+	var columns []string
+	var binds map[string]interface{}
+	if s.source != nil {
+		valuesSql, columns, binds, err = s.insertSelectSql(raw)
+	} else {
+		valuesSql, columns, binds, err = s.valuesSql(raw)
+	}
+	if err != nil {
+		return "", binds, err
+	}
 	resultSQL += valuesSql
 
-	if s.returning != "" {
-		resultSQL += " RETURNING " + s.returning
+	conflictSql, err := s.conflictSql(columns, raw, binds)
+	if err != nil {
+		return "", binds, err
+	}
+	resultSQL += conflictSql
+
+	returningSql, err := s.returningSql()
+	if err != nil {
+		return "", binds, err
 	}
+	resultSQL += returningSql
 
-	return resultSQL, binds
+	return resultSQL, binds, nil
 }
 
-//формирует values секцию для запроса INSERT и биндинг
-func (s *Selector) valuesSql(raw bool) (string, map[string]interface{}) {
-	binds := make(map[string]interface{})
-	resultSQL := ""
-	if len(s.values) == 0 {
-		return resultSQL, binds
+//формирует запрос типа SELECT * WHERE ...
+func (s *Selector) selectSql(raw bool) (string, map[string]interface{}, error) {
+	if s.count && (s.distinct || len(s.distinctOn) > 0 || len(s.setOperations) > 0) {
+		return s.distinctCountSql(raw)
 	}
-
-	// сначала нужно получить имена полей
-	fieldNames, fieldNumbers, err := s.getStructFieldNamesForDb(s.values[0])
+	selectionSql, err := s.selectionSql()
 	if err != nil {
-		fmt.Printf("### Error #1 in Selector.valuesSql: %v\n", err)
-		return resultSQL, binds
+		return "", map[string]interface{}{}, err
 	}
+	tableName, fromBinds, err := s.fromSql(raw)
+	if err != nil {
+		return "", fromBinds, err
+	}
+	resultSQL := fmt.Sprintf("SELECT %s FROM %s", selectionSql, tableName)
+	joinSql, binds, err := s.joinsSql(raw)
+	if err != nil {
+		return "", binds, err
+	}
+	resultSQL += joinSql
+	for k, v := range fromBinds {
+		binds[k] = v
+	}
+	whereSql, whereBinds, err := s.whereSql(raw)
+	if err != nil {
+		return "", binds, err
+	}
+	resultSQL += whereSql
+	for k, v := range whereBinds {
+		binds[k] = v
+	}
+	groupBySql, err := s.groupBySql()
+	if err != nil {
+		return "", binds, err
+	}
+	resultSQL += groupBySql
+	havingSql, err := s.having.sql("HAVING", s, raw, binds)
+	if err != nil {
+		return "", binds, err
+	}
+	resultSQL += havingSql
+	windowsSql, err := s.windowsSql()
+	if err != nil {
+		return "", binds, err
+	}
+	resultSQL += windowsSql
 
-	resultSQL += " ("
-	for i, field := range fieldNames {
-		if strings.ToLower(field) == "id" {
-			// пропуск столбца id
-			continue
-		}
-		resultSQL += field
-		if i < len(fieldNames)-1 {
-			resultSQL += ", "
-		}
+	setSql, err := s.setOperationsSql(raw, binds)
+	if err != nil {
+		return "", binds, err
 	}
-	resultSQL += ") VALUES "
+	resultSQL += setSql
 
-	// теперь нужно получить значения полей
-	for i, object := range s.values {
-		structValues, err := s.getStructFieldValues(object, fieldNumbers)
+	if s.orderBy != "" {
+		resultSQL += s.OrderBySql()
+	} else if len(s.orders) > 0 {
+		ordersSql, err := s.ordersSql(s.orders)
 		if err != nil {
-			fmt.Printf("### Error #2 in Selector.valuesSql: %v\n", err)
-			return "", binds
+			return "", binds, err
 		}
-		resultSQL += "("
-		for j, val := range structValues {
-			if strings.ToLower(fieldNames[j]) == "id" {
-				// пропуск столбца id
-				continue
-			}
+		resultSQL += " ORDER BY " + ordersSql
+	}
 
-			bindName := s.getBindingName(fieldNames[j], raw)
-			ph := s.getPlaceholder(bindName, raw)
-			resultSQL += fmt.Sprintf("%v", ph)
-			binds[bindName] = val
+	resultSQL += s.LimitSql()
+	resultSQL += s.OffsetSql()
 
-			if j < len(structValues)-1 {
-				resultSQL += ", "
-			}
-		}
-		resultSQL += ")"
-		if i < len(s.values)-1 {
-			resultSQL += ", "
-		}
+	lockSql, err := s.lockSql()
+	if err != nil {
+		return "", binds, err
 	}
+	resultSQL += lockSql
 
-	return resultSQL, binds
+	return resultSQL, binds, nil
 }
 
-/*
-Получает значения полей структуры
-Параметры:
-structure - структура данных
-fieldNumbers - срез номеров полей структуры
-Возвращает:
-[]interface{} - срез начений полей или пустой срез
-error - ошибка или nil
-*/
-func (sel *Selector) getStructFieldValues(structure interface{}, fieldNumbers []int) ([]interface{}, error) {
-	var res []interface{}
-	s := reflect.ValueOf(structure)
-
-	for _, fieldNumber := range fieldNumbers {
-		if s.Field(fieldNumber).CanInterface() {
-			field := s.Field(fieldNumber).Interface()
-			res = append(res, field)
-		} else {
-			return make([]interface{}, 0),
-				errors.New("getStructFieldValues: Ошибка преобразования элемента в интерфейс")
-		}
-
+//формирует запрос количества уникальных строк или строк объединения:
+//SELECT count(*) FROM (SELECT DISTINCT ...) AS "t"
+func (s *Selector) distinctCountSql(raw bool) (string, map[string]interface{}, error) {
+	inner := s.clone()
+	inner.count = false
+	inner.ctes = nil // секция WITH уже сформирована для внешнего запроса
+
+	outer := &Selector{
+		operation:        QUERY_SELECT,
+		fromQuery:        inner,
+		alias:            "t",
+		count:            true,
+		dialect:          s.dialect,
+		parameterPrefix:  s.parameterPrefix,
+		parameterCounter: s.parameterCounter,
 	}
-	return res, nil
+	sql, binds, err := outer.selectSql(raw)
+	s.parameterCounter = outer.parameterCounter
+	return sql, binds, err
 }
 
-/* получает отображение имён полей БД по тегу db: структуры или по имени, в значения
-использование имени в качестве ключа происходит если тег db: не указан
-structure - структура данных
-Возвращает:
-[]string - срез имён полей для БД или пустой срез
-[]int - номера этих полей в структуре или пустой срез
-error - ошибка или nil
-*/
-func (sel *Selector) getStructFieldNamesForDb(structure interface{}) ([]string, []int, error) {
-	s := reflect.ValueOf(structure)
-	fields := make([]string, 0)
-	fieldNumbers := make([]int, 0)
-	var err error
-
-	sType := s.Type()
-	for i := 0; i < s.NumField(); i++ { // i это номер поля структуры
-		fieldName := sType.Field(i).Name // имя поля структуры
-		value := fieldName
-
-		field, ok := reflect.TypeOf(structure).FieldByName(fieldName)
-		if !ok {
-			err = errors.New("reflect: Поле структуры не найдено!")
-			return make([]string, 0), make([]int, 0), err
+//формирует список полей сортировки, заданных через OrderBind
+func (s *Selector) ordersSql(orders []order) (string, error) {
+	items := make([]string, 0, len(orders))
+	for _, o := range orders {
+		if s.sortable != nil && !s.sortable[o.field] {
+			return "", fmt.Errorf("%w: %q", ErrColumnNotAllowed, o.field)
 		}
-
-		tagString := string(field.Tag)
-		keyIndex := strings.Index(tagString, "db:") // откуда начинаются значения для ключа db:
-		if keyIndex > -1 {                          // иначе value уже равно fieldName
-			tagString = tagString[keyIndex:] // отбрасываем то, что в строке до найденного ключа
-			// теперь ищем пару кавычек
-			q1Index := strings.Index(tagString, "\"") // индекс открывающей кавычки
-			if q1Index == -1 {
-				err = errors.New("Отсутствует открывающая кавычка")
-				return make([]string, 0), make([]int, 0), err
-			}
-			qString := tagString[q1Index:]                  // qString теперь равно строке начиная с открывающей кавычки
-			q2Index := strings.Index(qString[1:], "\"") + 1 // индекс закрывающей кавычки (минуем открывающую кавычку и увеличиваем индекс)
-			if q2Index == -1 {
-				err = errors.New("Отсутствует закрывающая кавычка")
-				return make([]string, 0), make([]int, 0), err
-			}
-			value = qString[1:q2Index] // то, что между кавычками
-			// теперь value содержит значение ключа "db"
-
-			if value == "-" { // пропускаем такое поле
-				continue
+		field, err := s.identifier(o.field)
+		if err != nil {
+			return "", err
+		}
+		item := field + " " + o.dir
+		if o.nulls != "" {
+			if s.dialect == DIALECT_MYSQL {
+				return "", fmt.Errorf("%w: NULLS FIRST/LAST в %v", ErrUnsupported, s.dialect)
 			}
+			item += " " + o.nulls
 		}
-		fields = append(fields, value)
-		fieldNumbers = append(fieldNumbers, i)
+		items = append(items, item)
 	}
-	return fields, fieldNumbers, nil
+	return strings.Join(items, ", "), nil
 }
 
-//формирует запрос типа SELECT * WHERE ...
-func (s *Selector) selectSql(raw bool) (string, map[string]interface{}) {
-	selection := "*"
+//формирует список выбираемых полей запроса SELECT
+func (s *Selector) selectionSql() (string, error) {
 	if s.count {
-		selection = "count(*)"
+		return "count(*)", nil
 	}
-	resultSQL := fmt.Sprintf("SELECT %s FROM \"%s\"", selection, s.tableName)
-	whereSql, binds := s.whereSql(raw)
-	resultSQL += whereSql
 
-	if s.orderBy != "" {
-		resultSQL += s.OrderBySql()
-	} else if len(s.orders) > 0 {
-		resultSQL += " ORDER BY "
-		for i, o := range s.orders {
-			bindName := s.getBindingName(o.field, raw)
-			ph := s.getPlaceholder(bindName, raw)
-			resultSQL += fmt.Sprintf("%v %v", ph, o.dir)
-			binds[bindName] = o.field
-			if i < len(s.orders)-1 {
-				resultSQL += ", "
+	resultSQL := ""
+	if len(s.distinctOn) > 0 {
+		if s.dialect != DIALECT_POSTGRESS {
+			return "", fmt.Errorf("%w: DISTINCT ON в %v", ErrUnsupported, s.dialect)
+		}
+		fields, err := s.identifierList(s.distinctOn)
+		if err != nil {
+			return "", err
+		}
+		resultSQL += fmt.Sprintf("DISTINCT ON (%s) ", fields)
+	} else if s.distinct {
+		resultSQL += "DISTINCT "
+	}
+
+	if len(s.columns) == 0 {
+		return resultSQL + "*", nil
+	}
+
+	selection := make([]string, 0, len(s.columns))
+	for _, c := range s.columns {
+		item := c.expr
+		if c.window != nil {
+			field, err := s.windowColumnSql(c.field, c.window)
+			if err != nil {
+				return "", err
 			}
+			item = field
+		} else if item == "" {
+			field, err := s.fieldSql(c.field)
+			if err != nil {
+				return "", err
+			}
+			item = field
 		}
+		if c.alias != "" {
+			alias, err := s.identifier(c.alias)
+			if err != nil {
+				return "", err
+			}
+			item += " AS " + alias
+		}
+		selection = append(selection, item)
 	}
 
-	resultSQL += s.LimitSql()
-	resultSQL += s.OffsetSql()
+	return resultSQL + strings.Join(selection, ", "), nil
+}
 
-	return resultSQL, binds
+//возвращает имя таблицы с псевдонимом в кавычках
+func (s *Selector) tableSql(tableName string, alias string) (string, error) {
+	resultSQL, err := s.identifier(tableName)
+	if err != nil || alias == "" {
+		return resultSQL, err
+	}
+	quotedAlias, err := s.identifier(alias)
+	if err != nil {
+		return "", err
+	}
+	return resultSQL + " AS " + quotedAlias, nil
 }
 
 //формирует where секцию для запроса и биндинг
-func (s *Selector) whereSql(raw bool) (string, map[string]interface{}) {
+func (s *Selector) whereSql(raw bool) (string, map[string]interface{}, error) {
 	binds := make(map[string]interface{})
-	resultSQL := ""
-	openBrackets := "" // часть строки, содержащая открывающие скобки
-	for _, cls := range s.clauses {
-		switch cls.(type) {
-		case bracket:
-			b := bool(cls.(bracket))
-			if !b {
-				resultSQL += ") "
-			} else {
-				openBrackets += " ("
-			}
-		case whereClause:
-			wc := cls.(whereClause)
-			bindName := s.getBindingName(wc.field, raw)
-			ph := s.getPlaceholder(bindName, raw)
-			resultSQL += fmt.Sprintf(" WHERE%s %v %v %v", openBrackets, wc.field, wc.operation, ph)
-			openBrackets = ""
-			binds[bindName] = wc.bind
-		case whereInClause:
-			wc := cls.(whereInClause)
-			bindNames := s.getBindingNamesIN(wc.field, raw, len(wc.binds))
-			ph := s.getPlaceholdersIN(bindNames, raw)
-			resultSQL += fmt.Sprintf(" WHERE%s %v %s %v", openBrackets, wc.field, "IN", ph)
-			openBrackets = ""
-			for i, _ := range wc.binds {
-				binds[bindNames[i]] = wc.binds[i]
-			}
-		case whereTrueClause:
-			resultSQL += fmt.Sprintf(" WHERE%s true", openBrackets)
-			openBrackets = ""
-		case andClause:
-			ac := cls.(andClause)
-			bindName := s.getBindingName(ac.field, raw)
-			ph := s.getPlaceholder(bindName, raw)
-			resultSQL += fmt.Sprintf(" AND%s %v %v %v", openBrackets, ac.field, ac.operation, ph)
-			openBrackets = ""
-			binds[bindName] = ac.bind
-		case andInClause:
-			ac := cls.(andInClause)
-			bindNames := s.getBindingNamesIN(ac.field, raw, len(ac.binds))
-			ph := s.getPlaceholdersIN(bindNames, raw)
-			resultSQL += fmt.Sprintf(" AND%s %v %s %v", openBrackets, ac.field, "IN", ph)
-			openBrackets = ""
-			for i, _ := range ac.binds {
-				binds[bindNames[i]] = ac.binds[i]
-			}
-		case orClause:
-			oc := cls.(orClause)
-			bindName := s.getBindingName(oc.field, raw)
-			ph := s.getPlaceholder(bindName, raw)
-			resultSQL += fmt.Sprintf(" OR%s %v %v %v", openBrackets, oc.field, oc.operation, ph)
-			openBrackets = ""
-			binds[bindName] = oc.bind
-		case orInClause:
-			oc := cls.(orInClause)
-			bindNames := s.getBindingNamesIN(oc.field, raw, len(oc.binds))
-			ph := s.getPlaceholdersIN(bindNames, raw)
-			resultSQL += fmt.Sprintf(" OR%s %v %s %v", openBrackets, oc.field, "IN", ph)
-			openBrackets = ""
-			for i, _ := range oc.binds {
-				binds[bindNames[i]] = oc.binds[i]
-			}
-		}
+	sql, err := s.whereTree().sql("WHERE", s, raw, binds)
+	return sql, binds, err
+}
+
+//возвращает заместитель для значения условия и добавляет значение в биндинг,
+//ссылка на поле (Ident) вставляется в запрос как идентификатор без биндинга,
+//вложенный запрос (*Selector) - в скобках вместе со своим биндингом
+func (s *Selector) bindValue(field string, bind interface{}, raw bool, binds map[string]interface{}) (string, error) {
+	if ident, ok := bind.(Ident); ok {
+		return s.identifier(string(ident))
+	}
+	if sub, ok := bind.(*Selector); ok {
+		return s.subqueryValue(sub, raw, binds)
 	}
 
-	return resultSQL, binds
+	bindName := s.getBindingName(field, raw)
+	binds[bindName] = bind
+	return s.getPlaceholder(bindName, raw), nil
 }
 
 /*
-	Возвращает секцию LIMIT запроса
+	Возвращает секцию LIMIT запроса. Если задано только смещение, а диалект
+	не допускает OFFSET без LIMIT (MySQL, SQLite), возвращается LIMIT без ограничения.
 */
 func (s *Selector) LimitSql() string {
 	if s.limit > 0 {
 		return fmt.Sprintf(" LIMIT %d", s.limit)
 	}
+	if s.offset > 0 && s.dialect.unlimited() != "" {
+		return " LIMIT " + s.dialect.unlimited()
+	}
 	return ""
 }
 
@@ -841,7 +1112,7 @@ func (s *Selector) OrderBySql() string {
 	Возвращает секцию WHERE запроса и биндинг
 */
 func (s *Selector) WhereSql() (string, map[string]interface{}) {
-	sql, binds := s.whereSql(false)
+	sql, binds, _ := s.whereSql(false)
 	if len(sql) > 6 {
 		return sql[6:], binds
 	}
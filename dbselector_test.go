@@ -25,7 +25,7 @@ func TestSelectorCorrectnessWithWhere(t *testing.T) {
 	selector.Select("user").Where("name", "=", "Vova")
 	sql, binds := selector.Sql()
 
-	gageSql := "SELECT * FROM \"user\" WHERE name = :name1"
+	gageSql := "SELECT * FROM \"user\" WHERE \"name\" = :name1"
 	compareSql(t, gageSql, sql)
 
 	gage := map[string]interface{}{"name1": "Vova"}
@@ -54,8 +54,8 @@ func TestSelectorCorrectnessWithComplexClause(t *testing.T) {
 		Or("active", "=", false).OrderBy("name").Limit(5).Offset(10)
 	sql, binds := selector.Sql()
 
-	gageSql := "SELECT * FROM \"user\" WHERE ( name = :name1 " +
-		"AND ( age > :age2 OR age < :age3) )  AND height IN (:height4,:height5,:height6) OR active = :active7 " +
+	gageSql := "SELECT * FROM \"user\" WHERE (\"name\" = :name1 " +
+		"AND (\"age\" > :age2 OR \"age\" < :age3)) AND \"height\" IN (:height4,:height5,:height6) OR \"active\" = :active7 " +
 		"ORDER BY name LIMIT 5 OFFSET 10"
 	compareSql(t, gageSql, sql)
 
@@ -71,30 +71,143 @@ func TestSelectorCorrectnessWithComplexClause(t *testing.T) {
 	compareBinds(t, binds, gage)
 }
 
+func TestSelectorClauseOrder(t *testing.T) {
+
+	// первое условие попадает в секцию WHERE, каким бы методом оно ни было добавлено
+	sel := &Selector{}
+	sel.Select("user").Or("name", "=", "Vova").And("age", ">", 18).Where("active", "=", true)
+	sql, binds := sel.Sql()
+
+	gageSql := "SELECT * FROM \"user\" WHERE \"name\" = :name1 AND \"age\" > :age2 AND \"active\" = :active3"
+	compareSql(t, gageSql, sql)
+
+	gage := map[string]interface{}{"name1": "Vova", "age2": 18, "active3": true}
+	compareBinds(t, binds, gage)
+
+	sel = &Selector{}
+	sel.Delete("user").OpenBracket().OrIn("id", []interface{}{1, 2}).Or("name", "=", "Vova").CloseBracket().
+		AndIn("age", []interface{}{}).OpenBracket().CloseBracket().And("active", "=", false)
+	sql, rawBinds := sel.RawSql()
+
+	gageSql = "DELETE FROM \"user\" WHERE (\"id\" IN ($1,$2) OR \"name\" = $3) AND \"active\" = $4"
+	compareSql(t, gageSql, sql)
+	compareBinds(t, rawBinds, []interface{}{1, 2, "Vova", false})
+}
+
 func TestSelectorWithCount(t *testing.T) {
 
 	sel := &Selector{}
 	sel.Select("user").Where("name", "=", "Vova").Count()
 	sql, binds := sel.Sql()
 
-	gageSql := "SELECT count(*) FROM \"user\" WHERE name = :name1"
+	gageSql := "SELECT count(*) FROM \"user\" WHERE \"name\" = :name1"
+	compareSql(t, gageSql, sql)
+
+	gage := map[string]interface{}{"name1": "Vova"}
+	compareBinds(t, binds, gage)
+}
+
+func TestSelectorDistinctCount(t *testing.T) {
+
+	sel := &Selector{}
+	sel.Select("user").Distinct().Columns("city").Where("active", "=", true).Count()
+	sql, binds, err := sel.BuildRaw()
+	compareError(t, nil, err)
+
+	gageSql := "SELECT count(*) FROM (SELECT DISTINCT \"city\" FROM \"user\" WHERE \"active\" = $1) AS \"t\""
+	compareSql(t, gageSql, sql)
+	compareBinds(t, binds, []interface{}{true})
+
+	active := (&Selector{}).Select("user").Where("active", "=", true)
+	sel = &Selector{}
+	sel.With("a", active).Select("a").DistinctOn("city").Columns("city", "name").Where("age", ">", 18).Count()
+	sql, _, err = sel.Build()
+	compareError(t, nil, err)
+
+	gageSql = "WITH \"a\" AS (SELECT * FROM \"user\" WHERE \"active\" = :active1) SELECT count(*) FROM " +
+		"(SELECT DISTINCT ON (\"city\") \"city\", \"name\" FROM \"a\" WHERE \"age\" > :age2) AS \"t\""
+	compareSql(t, gageSql, sql)
+}
+
+func TestSelectorColumns(t *testing.T) {
+
+	sel := &Selector{}
+	sel.Select("user").Columns("id", "u.name").ColumnAs("email", "login").
+		ColumnExpr("lower(city)", "city").Where("name", "=", "Vova")
+	sql, binds := sel.Sql()
+
+	gageSql := "SELECT \"id\", \"u\".\"name\", \"email\" AS \"login\", lower(city) AS \"city\" " +
+		"FROM \"user\" WHERE \"name\" = :name1"
 	compareSql(t, gageSql, sql)
 
 	gage := map[string]interface{}{"name1": "Vova"}
 	compareBinds(t, binds, gage)
 }
 
+func TestSelectorDistinct(t *testing.T) {
+
+	sel := &Selector{}
+	sel.Select("user").Distinct().Columns("city")
+	sql, _ := sel.Sql()
+	compareSql(t, "SELECT DISTINCT \"city\" FROM \"user\"", sql)
+
+	sel = &Selector{}
+	sel.Select("post").DistinctOn("author_id").Columns("author_id", "title")
+	sql, _ = sel.Sql()
+	compareSql(t, "SELECT DISTINCT ON (\"author_id\") \"author_id\", \"title\" FROM \"post\"", sql)
+}
+
 func TestSelectorOrderBind(t *testing.T) {
 
 	sel := &Selector{}
 	sel.Select("user").OrderBind("email", "ASC").OrderBind("name", "DESC")
 	sql, binds := sel.Sql()
 
-	gageSql := "SELECT * FROM \"user\" ORDER BY :email1 asc, :name2 desc"
+	gageSql := "SELECT * FROM \"user\" ORDER BY \"email\" asc, \"name\" desc"
+	compareSql(t, gageSql, sql)
+	compareBinds(t, binds, map[string]interface{}{})
+}
+
+func TestSelectorOrderBindNulls(t *testing.T) {
+
+	sel := &Selector{}
+	sel.Select("user").OrderBind("rating", "DESC NULLS LAST").OrderBind("u.name", "nulls first").
+		OrderBind("id", "up")
+	sql, _, err := sel.Build()
+	compareError(t, nil, err)
+
+	gageSql := "SELECT * FROM \"user\" ORDER BY \"rating\" desc nulls last, \"u\".\"name\" asc nulls first, \"id\" asc"
 	compareSql(t, gageSql, sql)
 
-	gage := map[string]interface{}{"email1": "email", "name2": "name"}
-	compareBinds(t, binds, gage)
+	sel = NewSelector(DIALECT_MYSQL)
+	sel.Select("user").OrderBind("rating", "desc nulls last")
+	_, _, err = sel.Build()
+	compareError(t, ErrUnsupported, err)
+
+	for _, dir := range []string{"desc nulls", "nulls", "asc nulls middle", "desc last", "nulls first asc"} {
+		sel = &Selector{}
+		sel.Select("user").OrderBind("rating", dir)
+		_, _, err = sel.Build()
+		compareError(t, ErrInvalidOrder, err)
+	}
+}
+
+func TestSelectorSortableColumns(t *testing.T) {
+
+	sel := &Selector{}
+	sel.Select("user").SortableColumns("name", "email").OrderBind("email", "desc")
+	sql, _, err := sel.Build()
+	compareError(t, nil, err)
+	compareSql(t, "SELECT * FROM \"user\" ORDER BY \"email\" desc", sql)
+
+	sel.OrderBind("password", "asc")
+	_, _, err = sel.Build()
+	compareError(t, ErrColumnNotAllowed, err)
+
+	sel = &Selector{}
+	sel.Select("user").OrderBind("name; DROP TABLE user", "asc")
+	_, _, err = sel.Build()
+	compareError(t, ErrInvalidIdentifier, err)
 }
 
 func TestSelectorDelete(t *testing.T) {
@@ -102,7 +215,7 @@ func TestSelectorDelete(t *testing.T) {
 	sel.Delete("user").Where("id", ">", "7")
 	sql, binds := sel.Sql()
 
-	gageSql := "DELETE FROM \"user\" WHERE id > :id1"
+	gageSql := "DELETE FROM \"user\" WHERE \"id\" > :id1"
 	compareSql(t, gageSql, sql)
 
 	gage := map[string]interface{}{"id1": "7"}
@@ -116,8 +229,8 @@ func TestSelectorUpdate(t *testing.T) {
 	sel.Where("active", "=", true).Or("id", "=", 77)
 	sql, binds := sel.Sql()
 
-	gageSql := "UPDATE \"post\" SET title = :title1, author_id = :author_id2" +
-		" WHERE active = :active3 OR id = :id4"
+	gageSql := "UPDATE \"post\" SET \"title\" = :title1, \"author_id\" = :author_id2" +
+		" WHERE \"active\" = :active3 OR \"id\" = :id4"
 	compareSql(t, gageSql, sql)
 
 	gageBind := map[string]interface{}{
@@ -137,8 +250,8 @@ func TestSelectorInsertEmptyValue(t *testing.T) {
 	sel.Insert("table").Values(emptyValue)
 	sql, binds := sel.Sql()
 
-	gageSql := "INSERT INTO \"table\""
-	compareSql(t, gageSql, sql)
+	//без значений запрос не формируется
+	compareSql(t, "", sql)
 
 	gageBind := map[string]interface{}{}
 	compareBinds(t, binds, gageBind)
@@ -168,7 +281,7 @@ func TestSelectorInsertOneItem(t *testing.T) {
 	sel.Insert("table").Values([]interface{}{item})
 	sql, binds := sel.Sql()
 
-	gageSql := "INSERT INTO \"table\" (Num_A, num_b, time, num_c) VALUES " +
+	gageSql := "INSERT INTO \"table\" (\"Num_A\", \"num_b\", \"time\", \"num_c\") VALUES " +
 		"(:Num_A1, :num_b2, :time3, :num_c4)"
 	compareSql(t, gageSql, sql)
 
@@ -200,7 +313,7 @@ func TestSelectorInsert(t *testing.T) {
 	sel.Insert("table").Values([]interface{}{item1, item2})
 	sql, binds := sel.Sql()
 
-	gageSql := "INSERT INTO \"table\" (Num_A, num_b, time, num_c) VALUES " +
+	gageSql := "INSERT INTO \"table\" (\"Num_A\", \"num_b\", \"time\", \"num_c\") VALUES " +
 		"(:Num_A1, :num_b2, :time3, :num_c4), (:Num_A5, :num_b6, :time7, :num_c8)"
 	compareSql(t, gageSql, sql)
 
@@ -230,19 +343,69 @@ func TestSelectorInsertReturning(t *testing.T) {
 	sel.Returning("id", "num_b")
 	sql, _ := sel.Sql()
 
-	gageSql := "INSERT INTO \"table\" (Num_A, num_b, time, num_c) VALUES " +
+	gageSql := "INSERT INTO \"table\" (\"Num_A\", \"num_b\", \"time\", \"num_c\") VALUES " +
 		"(:Num_A1, :num_b2, :time3, :num_c4) " +
-		"RETURNING id,num_b"
+		"RETURNING \"id\", \"num_b\""
+	compareSql(t, gageSql, sql)
+}
+
+type keyStruct struct {
+	Uuid   string `db:"uuid,pk"`
+	Name   string `db:"name"`
+	Serial int64  `db:"serial,auto"`
+	Id     int64  `db:"id"`
+}
+
+type lastIdStruct struct {
+	Name string `db:"name"`
+	Id   int64
+}
+
+func TestSelectorInsertKeys(t *testing.T) {
+
+	item := keyStruct{Uuid: "9c5b94b1", Name: "Vova", Serial: 5, Id: 7}
+
+	sel := &Selector{}
+	sel.Insert("user").Values([]interface{}{item})
+	sql, binds := sel.Sql()
+
+	gageSql := "INSERT INTO \"user\" (\"uuid\", \"name\", \"id\") VALUES (:uuid1, :name2, :id3)"
+	compareSql(t, gageSql, sql)
+	compareBinds(t, binds, map[string]interface{}{"uuid1": "9c5b94b1", "name2": "Vova", "id3": int64(7)})
+
+	sel = &Selector{}
+	sel.Insert("user").Values([]interface{}{item}).ExplicitKeys()
+	sql, _ = sel.Sql()
+
+	gageSql = "INSERT INTO \"user\" (\"uuid\", \"name\", \"serial\", \"id\") VALUES (:uuid1, :name2, :serial3, :id4)"
 	compareSql(t, gageSql, sql)
 }
 
+func TestSelectorInsertLastId(t *testing.T) {
+
+	sel := &Selector{}
+	sel.Insert("user").Values([]interface{}{lastIdStruct{Name: "Vova", Id: 3}, lastIdStruct{Name: "Dima"}})
+	sql, binds := sel.RawSql()
+
+	gageSql := "INSERT INTO \"user\" (\"name\") VALUES ($1), ($2)"
+	compareSql(t, gageSql, sql)
+	compareBinds(t, binds, []interface{}{"Vova", "Dima"})
+
+	sel = &Selector{}
+	sel.Insert("user").Values([]interface{}{lastIdStruct{Name: "Vova", Id: 3}}).ExplicitKeys()
+	sql, binds = sel.RawSql()
+
+	compareSql(t, "INSERT INTO \"user\" (\"name\", \"Id\") VALUES ($1, $2)", sql)
+	compareBinds(t, binds, []interface{}{"Vova", int64(3)})
+}
+
 func TestRepeatingParam(t *testing.T) {
 
 	sel := &Selector{}
 	sel.Select("user").Where("id", ">", 10).And("id", "<", 55)
 	sql, binds := sel.Sql()
 
-	gageSql := "SELECT * FROM \"user\" WHERE id > :id1 AND id < :id2"
+	gageSql := "SELECT * FROM \"user\" WHERE \"id\" > :id1 AND \"id\" < :id2"
 	compareSql(t, gageSql, sql)
 
 	gageBinds := map[string]interface{}{"id1": 10, "id2": 55}
@@ -256,10 +419,10 @@ func TestParamPrefix(t *testing.T) {
 	sel.Select("table").Where("name", "=", "Vova").OrderBind("id", "DESC")
 	sql, binds := sel.Sql()
 
-	gageSql := "SELECT * FROM \"table\" WHERE name = :q1_name1 ORDER BY :q1_id2 desc"
+	gageSql := "SELECT * FROM \"table\" WHERE \"name\" = :q1_name1 ORDER BY \"id\" desc"
 	compareSql(t, gageSql, sql)
 
-	gageBinds := map[string]interface{}{"q1_name1": "Vova", "q1_id2": "id"}
+	gageBinds := map[string]interface{}{"q1_name1": "Vova"}
 	compareBinds(t, binds, gageBinds)
 }
 
@@ -269,7 +432,7 @@ func TestRawQuery(t *testing.T) {
 	sel.Delete("user").Where("id", ">", 137).Or("name", "LIKE", "%Vov%")
 	sql, binds := sel.RawSql()
 
-	gageSql := "DELETE FROM \"user\" WHERE id > $1 OR name LIKE $2"
+	gageSql := "DELETE FROM \"user\" WHERE \"id\" > $1 OR \"name\" LIKE $2"
 	compareSql(t, gageSql, sql)
 
 	gageBinds := []interface{}{137, "%Vov%"}
@@ -0,0 +1,131 @@
+package dbselector
+
+import (
+	"fmt"
+	"strings"
+)
+
+/*Создает Selector для указанного диалекта SQL
+Параметры:
+	dialect - диалект: DIALECT_POSTGRESS, DIALECT_MYSQL или DIALECT_SQLITE
+Результат:
+	ссылка на новый Selector
+Пример использования:
+	selector := NewSelector(DIALECT_MYSQL)
+	selector.Select("user").Where("id", "=", 7)
+	sql, binds := selector.RawSql()
+	тогда sql содержит: SELECT * FROM `user` WHERE `id` = ?
+*/
+func NewSelector(dialect SqlDialect) *Selector {
+	return &Selector{dialect: dialect}
+}
+
+/*Задает диалект SQL, от которого зависят заместители параметров в RawSql,
+кавычки для идентификаторов, поддержка RETURNING и DISTINCT ON, логические литералы
+и синтаксис LIMIT/OFFSET. По умолчанию используется DIALECT_POSTGRESS.
+Параметры:
+	dialect - диалект: DIALECT_POSTGRESS, DIALECT_MYSQL или DIALECT_SQLITE
+Результат:
+	ссылка Selector на самого себя
+Пример использования:
+	selector := &Selector{}
+	selector.Dialect(DIALECT_SQLITE).Select("user")
+*/
+func (s *Selector) Dialect(dialect SqlDialect) *Selector {
+	s.dialect = dialect
+	return s
+}
+
+// Возвращает название диалекта
+func (d SqlDialect) String() string {
+	switch d {
+	case DIALECT_POSTGRESS:
+		return "PostgreSQL"
+	case DIALECT_MYSQL:
+		return "MySQL"
+	case DIALECT_SQLITE:
+		return "SQLite"
+	default:
+		return fmt.Sprintf("SqlDialect(%d)", int(d))
+	}
+}
+
+//возвращает заместитель параметра для позиционного (raw) запроса,
+//bindName имеет вид $N
+func (d SqlDialect) placeholder(bindName string) string {
+	if d == DIALECT_POSTGRESS {
+		return bindName
+	}
+	return "?"
+}
+
+//заключает идентификатор в кавычки, части идентификатора вида table.column
+//заключаются в кавычки по отдельности, звездочка остается без изменений,
+//кавычки внутри имени экранируются удвоением
+func (d SqlDialect) quoteIdentifier(name string) string {
+	quote := "\""
+	if d == DIALECT_MYSQL {
+		quote = "`"
+	}
+
+	parts := strings.Split(name, ".")
+	for i, part := range parts {
+		if part == "*" {
+			continue
+		}
+		parts[i] = quote + strings.Replace(part, quote, quote+quote, -1) + quote
+	}
+	return strings.Join(parts, ".")
+}
+
+//возвращает true, если диалект поддерживает секцию RETURNING
+func (d SqlDialect) supportsReturning() bool {
+	return d != DIALECT_MYSQL
+}
+
+//возвращает логический литерал диалекта
+func (d SqlDialect) boolLiteral(b bool) string {
+	if d == DIALECT_SQLITE {
+		if b {
+			return "1"
+		}
+		return "0"
+	}
+	if b {
+		return "true"
+	}
+	return "false"
+}
+
+//возвращает значение LIMIT без ограничения количества строк для диалектов,
+//в которых OFFSET не может использоваться без LIMIT, иначе пустую строку
+func (d SqlDialect) unlimited() string {
+	switch d {
+	case DIALECT_MYSQL:
+		return "18446744073709551615"
+	case DIALECT_SQLITE:
+		return "-1"
+	default:
+		return ""
+	}
+}
+
+//возвращает секцию RETURNING запроса или ошибку, если диалект ее не поддерживает
+func (s *Selector) returningSql() (string, error) {
+	if len(s.returning) == 0 {
+		return "", nil
+	}
+	if !s.dialect.supportsReturning() {
+		return "", fmt.Errorf("%w: RETURNING в %v", ErrUnsupported, s.dialect)
+	}
+	//допускается звездочка: RETURNING * или RETURNING "t".*
+	fields := make([]string, 0, len(s.returning))
+	for _, name := range s.returning {
+		field, err := s.columnIdentifier(name)
+		if err != nil {
+			return "", err
+		}
+		fields = append(fields, field)
+	}
+	return " RETURNING " + strings.Join(fields, ", "), nil
+}
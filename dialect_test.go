@@ -0,0 +1,55 @@
+package dbselector
+
+import "testing"
+
+func TestDialectMysql(t *testing.T) {
+
+	sel := NewSelector(DIALECT_MYSQL)
+	sel.Select("user").Columns("id", "name").WhereIn("id", []interface{}{}).
+		And("name", "=", "Vova").Offset(10)
+	sql, binds := sel.RawSql()
+
+	gageSql := "SELECT `id`, `name` FROM `user` WHERE true AND `name` = ? LIMIT 18446744073709551615 OFFSET 10"
+	compareSql(t, gageSql, sql)
+	compareBinds(t, binds, []interface{}{"Vova"})
+}
+
+func TestDialectSqlite(t *testing.T) {
+
+	sel := &Selector{}
+	sel.Dialect(DIALECT_SQLITE).Select("user").WhereIn("id", []interface{}{}).
+		AndIn("age", []interface{}{18, 19}).Offset(5)
+	sql, binds := sel.RawSql()
+
+	gageSql := "SELECT * FROM \"user\" WHERE 1 AND \"age\" IN (?,?) LIMIT -1 OFFSET 5"
+	compareSql(t, gageSql, sql)
+	compareBinds(t, binds, []interface{}{18, 19})
+}
+
+func TestDialectReturning(t *testing.T) {
+
+	sel := NewSelector(DIALECT_MYSQL)
+	sel.Delete("user").Where("id", "=", 7).Returning("id")
+	_, _, err := sel.BuildRaw()
+	compareError(t, ErrUnsupported, err)
+
+	sel = NewSelector(DIALECT_SQLITE)
+	sel.Delete("user").Where("id", "=", 7).Returning("id")
+	sql, _ := sel.RawSql()
+	compareSql(t, "DELETE FROM \"user\" WHERE \"id\" = ? RETURNING \"id\"", sql)
+
+	sel = NewSelector(DIALECT_POSTGRESS)
+	sel.Delete("user").Where("id", "=", 7).Returning("id")
+	sql, _ = sel.RawSql()
+	compareSql(t, "DELETE FROM \"user\" WHERE \"id\" = $1 RETURNING \"id\"", sql)
+
+	sel = NewSelector(DIALECT_POSTGRESS)
+	sel.Delete("user").Where("id", "=", 7).Returning("*")
+	sql, _ = sel.RawSql()
+	compareSql(t, "DELETE FROM \"user\" WHERE \"id\" = $1 RETURNING *", sql)
+
+	sel = NewSelector(DIALECT_SQLITE)
+	sel.Update("user").Set("name", "Vova").Returning("user.*", "id")
+	sql, _ = sel.RawSql()
+	compareSql(t, "UPDATE \"user\" SET \"name\" = ? RETURNING \"user\".*, \"id\"", sql)
+}
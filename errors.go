@@ -0,0 +1,38 @@
+package dbselector
+
+import "errors"
+
+// Ошибки, возвращаемые Build и BuildRaw. Ошибки могут быть обернуты с уточнением,
+// поэтому для проверки следует использовать errors.Is.
+var (
+	ErrNoTable            = errors.New("dbselector: не указано имя таблицы")
+	ErrNoSet              = errors.New("dbselector: для UPDATE не задано ни одного поля через Set")
+	ErrNoValues           = errors.New("dbselector: для INSERT не заданы значения через Values")
+	ErrUnbalancedBrackets = errors.New("dbselector: открывающие и закрывающие скобки не сбалансированы")
+	ErrNotStruct          = errors.New("dbselector: значение для INSERT не является структурой")
+	ErrMixedValues        = errors.New("dbselector: значения для INSERT имеют разные типы")
+	ErrNoJoin             = errors.New("dbselector: условие On указано без Join")
+	ErrJoinCondition      = errors.New("dbselector: условие ON обязательно для всех соединений кроме CROSS JOIN")
+	ErrUnsupported        = errors.New("dbselector: не поддерживается диалектом")
+	ErrInvalidOperator    = errors.New("dbselector: недопустимый оператор сравнения")
+	ErrInvalidIdentifier  = errors.New("dbselector: недопустимое имя таблицы или поля")
+	ErrColumnNotAllowed   = errors.New("dbselector: сортировка по полю не разрешена")
+	ErrInvalidOrder       = errors.New("dbselector: недопустимое направление сортировки")
+	ErrInvalidTag         = errors.New("dbselector: недопустимый тег db")
+	ErrNoConflict         = errors.New("dbselector: DoNothing или DoUpdate указано без OnConflict")
+	ErrConflictClause     = errors.New("dbselector: некорректная секция ON CONFLICT")
+	ErrNotSelect          = errors.New("dbselector: вложенный запрос не является запросом SELECT")
+	ErrInvalidWindow      = errors.New("dbselector: недопустимая оконная функция или описание окна")
+	ErrLockClause         = errors.New("dbselector: некорректная блокировка строк")
+	ErrKeyset             = errors.New("dbselector: некорректная позиция постраничной выборки по ключу")
+	ErrInvalidCursor      = errors.New("dbselector: недействительный курсор")
+	ErrInvalidPage        = errors.New("dbselector: недопустимое количество строк на странице")
+)
+
+//запоминает первую ошибку, допущенную при построении запроса,
+//она будет возвращена при формировании запроса
+func (s *Selector) setError(err error) {
+	if s.err == nil {
+		s.err = err
+	}
+}
@@ -0,0 +1,68 @@
+package dbselector
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestBuild(t *testing.T) {
+
+	sel := &Selector{}
+	sel.Select("user").Where("name", "=", "Vova")
+	sql, binds, err := sel.Build()
+	compareError(t, nil, err)
+	compareSql(t, "SELECT * FROM \"user\" WHERE \"name\" = :name1", sql)
+	compareBinds(t, binds, map[string]interface{}{"name1": "Vova"})
+
+	sql, rawBinds, err := sel.BuildRaw()
+	compareError(t, nil, err)
+	compareSql(t, "SELECT * FROM \"user\" WHERE \"name\" = $1", sql)
+	compareBinds(t, rawBinds, []interface{}{"Vova"})
+}
+
+func TestBuildErrors(t *testing.T) {
+
+	tests := []struct {
+		name     string
+		selector *Selector
+		err      error
+	}{
+		{"no table", (&Selector{}).Where("id", "=", 1), ErrNoTable},
+		{"no set", (&Selector{}).Update("user").Where("id", "=", 1), ErrNoSet},
+		{"no values", (&Selector{}).Insert("user"), ErrNoValues},
+		{"not struct", (&Selector{}).Insert("user").Values([]interface{}{1}), ErrNotStruct},
+		{"mixed values", (&Selector{}).Insert("user").Values([]interface{}{testStruct{}, struct{ A int }{}}), ErrMixedValues},
+		{"unclosed bracket", (&Selector{}).Select("user").OpenBracket().Where("id", "=", 1), ErrUnbalancedBrackets},
+		{"extra bracket", (&Selector{}).Select("user").Where("id", "=", 1).CloseBracket(), ErrUnbalancedBrackets},
+		{"on without join", (&Selector{}).Select("user").On("id", "=", 1), ErrNoJoin},
+		{"join without on", (&Selector{}).Select("user").Join("post", "p"), ErrJoinCondition},
+		{"full join mysql", NewSelector(DIALECT_MYSQL).Select("user").FullJoin("post", "p").On("p.id", "=", 1), ErrUnsupported},
+		{"distinct on sqlite", NewSelector(DIALECT_SQLITE).Select("user").DistinctOn("name"), ErrUnsupported},
+		{"statement after with", (&Selector{}).With("u", (&Selector{}).Select("user")).Update("u"), ErrNoSet},
+	}
+
+	for _, test := range tests {
+		sql, _, err := test.selector.Build()
+		if !errors.Is(err, test.err) {
+			t.Errorf("%s: ожидалась ошибка %v, получено %v", test.name, test.err, err)
+		}
+		if sql != "" {
+			t.Errorf("%s: при ошибке возвращен запрос %v", test.name, sql)
+		}
+
+		sql, binds := test.selector.Sql()
+		if sql != "" || len(binds) != 0 {
+			t.Errorf("%s: Sql при ошибке вернул запрос %v и параметры %v", test.name, sql, binds)
+		}
+		rawSql, rawBinds := test.selector.RawSql()
+		if rawSql != "" || len(rawBinds) != 0 {
+			t.Errorf("%s: RawSql при ошибке вернул запрос %v и параметры %v", test.name, rawSql, rawBinds)
+		}
+	}
+}
+
+func compareError(t *testing.T, gage error, err error) {
+	if !errors.Is(err, gage) {
+		t.Errorf("Ожидалась ошибка: %v\nВозвращено: %v\n", gage, err)
+	}
+}
@@ -0,0 +1,211 @@
+package dbselector
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"reflect"
+)
+
+/*Querier - соединение с БД, через которое выполняются запросы: *sql.DB, *sql.Tx или *sql.Conn.
+Запросы передаются с позиционными параметрами в стиле диалекта селектора ($1 или ?).
+*/
+type Querier interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// Row - результат QueryRow, аналог *sql.Row, который также хранит ошибку построения запроса
+type Row struct {
+	row *sql.Row
+	err error
+}
+
+/*Выполняет запрос, не возвращающий строк: INSERT, UPDATE или DELETE
+Параметры:
+	ctx - контекст запроса
+	db - соединение с БД
+Результат:
+	1. результат выполнения запроса
+	2. ошибка построения или выполнения запроса или nil
+Пример использования:
+	selector := NewSelector(DIALECT_MYSQL)
+	res, err := selector.Update("user").Set("active", false).Where("id", "=", 7).Exec(ctx, db)
+*/
+func (s *Selector) Exec(ctx context.Context, db Querier) (sql.Result, error) {
+	query, args, err := s.BuildRaw()
+	if err != nil {
+		return nil, err
+	}
+	return db.ExecContext(ctx, query, args...)
+}
+
+/*Выполняет запрос, возвращающий строки. Строки нужно закрыть после чтения.
+Параметры:
+	ctx - контекст запроса
+	db - соединение с БД
+Результат:
+	1. строки результата
+	2. ошибка построения или выполнения запроса или nil
+Пример использования:
+	selector := &Selector{}
+	rows, err := selector.Select("user").Columns("id", "name").Where("active", "=", true).Query(ctx, db)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+*/
+func (s *Selector) Query(ctx context.Context, db Querier) (*sql.Rows, error) {
+	query, args, err := s.BuildRaw()
+	if err != nil {
+		return nil, err
+	}
+	return db.QueryContext(ctx, query, args...)
+}
+
+/*Выполняет запрос, возвращающий не более одной строки. Ошибка построения или
+выполнения запроса возвращается из Scan, как и для *sql.Row.
+Параметры:
+	ctx - контекст запроса
+	db - соединение с БД
+Результат:
+	строка результата
+Пример использования:
+	selector := &Selector{}
+	var total int
+	err := selector.Select("user").Count().Where("active", "=", true).QueryRow(ctx, db).Scan(&total)
+*/
+func (s *Selector) QueryRow(ctx context.Context, db Querier) *Row {
+	query, args, err := s.BuildRaw()
+	if err != nil {
+		return &Row{err: err}
+	}
+	return &Row{row: db.QueryRowContext(ctx, query, args...)}
+}
+
+// Копирует колонки строки в dest, как (*sql.Row).Scan. Если строки нет, возвращает sql.ErrNoRows
+func (r *Row) Scan(dest ...interface{}) error {
+	if r.err != nil {
+		return r.err
+	}
+	return r.row.Scan(dest...)
+}
+
+// Возвращает ошибку построения или выполнения запроса, не читая строку
+func (r *Row) Err() error {
+	if r.err != nil {
+		return r.err
+	}
+	return r.row.Err()
+}
+
+/*Выполняет запрос и читает все строки результата в срез структур. Колонки результата
+сопоставляются полям структуры по тегам db так же, как при INSERT, включая встроенные
+структуры, префиксы и опцию json. Колонка, для которой нет поля, приводит к ошибке.
+Параметры:
+	ctx - контекст запроса
+	db - соединение с БД
+	dest - указатель на срез структур или указателей на структуры
+Результат:
+	ошибка или nil
+Пример использования:
+	var users []User
+	selector := &Selector{}
+	err := selector.Select("user").Where("active", "=", true).QueryStructs(ctx, db, &users)
+*/
+func (s *Selector) QueryStructs(ctx context.Context, db Querier, dest interface{}) error {
+	slice := reflect.ValueOf(dest)
+	if slice.Kind() != reflect.Ptr || slice.Elem().Kind() != reflect.Slice {
+		return fmt.Errorf("%w: %T не является указателем на срез", ErrNotStruct, dest)
+	}
+	slice = slice.Elem()
+	elemType := slice.Type().Elem()
+	meta, err := getStructMeta(elemType)
+	if err != nil {
+		return fmt.Errorf("%w: %v", err, elemType)
+	}
+
+	rows, err := s.Query(ctx, db)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+	fields := make([]structField, 0, len(columns))
+	for _, name := range columns {
+		i, ok := meta.columns[name]
+		if !ok {
+			return fmt.Errorf("%w: нет поля для колонки %q в %v", ErrNotStruct, name, elemType)
+		}
+		fields = append(fields, meta.fields[i])
+	}
+
+	for rows.Next() {
+		item := reflect.New(indirectType(elemType)).Elem()
+		if err := scanStruct(rows, item, fields); err != nil {
+			return err
+		}
+		if elemType.Kind() == reflect.Ptr {
+			item = item.Addr()
+		}
+		slice.Set(reflect.Append(slice, item))
+	}
+	return rows.Err()
+}
+
+//читает текущую строку в структуру item, fields - описания полей в порядке колонок
+func scanStruct(rows *sql.Rows, item reflect.Value, fields []structField) error {
+	targets := make([]interface{}, len(fields))
+	for i, f := range fields {
+		if f.json {
+			targets[i] = new([]byte)
+			continue
+		}
+		targets[i] = allocFieldByIndex(item, f.index).Addr().Interface()
+	}
+	if err := rows.Scan(targets...); err != nil {
+		return err
+	}
+
+	for i, f := range fields {
+		if !f.json {
+			continue
+		}
+		data := *targets[i].(*[]byte)
+		if data == nil {
+			continue
+		}
+		if err := json.Unmarshal(data, allocFieldByIndex(item, f.index).Addr().Interface()); err != nil {
+			return fmt.Errorf("dbselector: поле %s: %w", f.name, err)
+		}
+	}
+	return nil
+}
+
+//возвращает поле по пути index, создавая вложенные структуры для nil указателей
+func allocFieldByIndex(v reflect.Value, index []int) reflect.Value {
+	for _, i := range index {
+		if v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(i)
+	}
+	return v
+}
+
+//возвращает тип без учета указателей
+func indirectType(t reflect.Type) reflect.Type {
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t
+}
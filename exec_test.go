@@ -0,0 +1,196 @@
+package dbselector
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+// тестовый драйвер БД, запоминающий последний запрос и возвращающий заданные строки
+type fakeDriver struct {
+	mu      sync.Mutex
+	query   string
+	args    []driver.Value
+	columns []string
+	rows    [][]driver.Value
+}
+
+type fakeConn struct{ d *fakeDriver }
+
+type fakeStmt struct {
+	d     *fakeDriver
+	query string
+}
+
+type fakeRows struct {
+	columns []string
+	rows    [][]driver.Value
+}
+
+var fakeDB = &fakeDriver{}
+
+func init() {
+	sql.Register("dbselector_fake", fakeDB)
+}
+
+func (d *fakeDriver) Open(name string) (driver.Conn, error) { return fakeConn{d}, nil }
+
+func (c fakeConn) Prepare(query string) (driver.Stmt, error) { return fakeStmt{c.d, query}, nil }
+func (c fakeConn) Close() error                              { return nil }
+func (c fakeConn) Begin() (driver.Tx, error)                 { return nil, errors.New("not supported") }
+
+func (s fakeStmt) Close() error  { return nil }
+func (s fakeStmt) NumInput() int { return -1 }
+
+func (s fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+	s.d.query, s.d.args = s.query, args
+	return driver.RowsAffected(1), nil
+}
+
+func (s fakeStmt) Query(args []driver.Value) (driver.Rows, error) {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+	s.d.query, s.d.args = s.query, args
+	return &fakeRows{columns: s.d.columns, rows: s.d.rows}, nil
+}
+
+func (r *fakeRows) Columns() []string { return r.columns }
+func (r *fakeRows) Close() error      { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.rows) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.rows[0])
+	r.rows = r.rows[1:]
+	return nil
+}
+
+//открывает тестовую БД, которая вернет на запрос заданные строки
+func openFakeDB(t *testing.T, columns []string, rows ...[]driver.Value) *sql.DB {
+	fakeDB.mu.Lock()
+	fakeDB.columns, fakeDB.rows = columns, rows
+	fakeDB.mu.Unlock()
+	db, err := sql.Open("dbselector_fake", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+//сравнивает последний выполненный запрос с ожидаемым
+func compareQuery(t *testing.T, gageSql string, gageArgs []driver.Value) {
+	t.Helper()
+	fakeDB.mu.Lock()
+	defer fakeDB.mu.Unlock()
+	compareSql(t, gageSql, fakeDB.query)
+	if !reflect.DeepEqual(fakeDB.args, gageArgs) {
+		t.Errorf("Ожидались параметры: %v\nВозвращено: %v", gageArgs, fakeDB.args)
+	}
+}
+
+func TestExec(t *testing.T) {
+
+	db := openFakeDB(t, nil)
+	defer db.Close()
+	ctx := context.Background()
+
+	sel := NewSelector(DIALECT_MYSQL)
+	res, err := sel.Update("user").Set("active", false).Where("id", "=", 7).Exec(ctx, db)
+	compareError(t, nil, err)
+	if n, _ := res.RowsAffected(); n != 1 {
+		t.Errorf("wrong rows affected: %d", n)
+	}
+	compareQuery(t, "UPDATE `user` SET `active` = ? WHERE `id` = ?", []driver.Value{false, int64(7)})
+
+	conn, err := db.Conn(ctx)
+	compareError(t, nil, err)
+	defer conn.Close()
+	_, err = (&Selector{}).Delete("user").Where("id", "=", 7).Exec(ctx, conn)
+	compareError(t, nil, err)
+	compareQuery(t, "DELETE FROM \"user\" WHERE \"id\" = $1", []driver.Value{int64(7)})
+
+	_, err = (&Selector{}).Update("user").Exec(ctx, db)
+	compareError(t, ErrNoSet, err)
+}
+
+func TestQueryRow(t *testing.T) {
+
+	db := openFakeDB(t, []string{"count"}, []driver.Value{int64(42)})
+	defer db.Close()
+	ctx := context.Background()
+
+	var total int
+	err := (&Selector{}).Select("user").Count().Where("active", "=", true).QueryRow(ctx, db).Scan(&total)
+	compareError(t, nil, err)
+	if total != 42 {
+		t.Errorf("wrong total: %d", total)
+	}
+	compareQuery(t, "SELECT count(*) FROM \"user\" WHERE \"active\" = $1", []driver.Value{true})
+
+	empty := openFakeDB(t, []string{"count"})
+	defer empty.Close()
+	err = (&Selector{}).Select("user").QueryRow(ctx, empty).Scan(&total)
+	compareError(t, sql.ErrNoRows, err)
+
+	row := (&Selector{}).Select("user").CloseBracket().QueryRow(ctx, db)
+	compareError(t, ErrUnbalancedBrackets, row.Err())
+	compareError(t, ErrUnbalancedBrackets, row.Scan(&total))
+}
+
+func TestQueryStructs(t *testing.T) {
+
+	created := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	db := openFakeDB(t, []string{"id", "name", "created_at", "home_city", "settings"},
+		[]driver.Value{int64(1), "Vova", created, "Moscow", []byte(`{"lang":"ru"}`)},
+		[]driver.Value{int64(2), "Dima", created, nil, nil},
+	)
+	defer db.Close()
+	ctx := context.Background()
+
+	type user struct {
+		Id   int64  `db:"id,pk"`
+		Name string `db:"name"`
+		Timestamps
+		Home *struct {
+			City sql.NullString `db:"city"`
+		} `db:"home_,prefix"`
+		Settings map[string]string `db:"settings,json"`
+	}
+
+	var users []*user
+	sel := &Selector{}
+	err := sel.Select("user").Columns("id", "name", "created_at", "home_city", "settings").
+		Where("active", "=", true).QueryStructs(ctx, db, &users)
+	compareError(t, nil, err)
+	compareQuery(t, "SELECT \"id\", \"name\", \"created_at\", \"home_city\", \"settings\" FROM \"user\" WHERE \"active\" = $1",
+		[]driver.Value{true})
+
+	if len(users) != 2 {
+		t.Fatalf("wrong users count: %d", len(users))
+	}
+	if users[0].Id != 1 || users[0].Name != "Vova" || !users[0].Created.Equal(created) ||
+		users[0].Home.City.String != "Moscow" || users[0].Settings["lang"] != "ru" {
+		t.Errorf("wrong first user: %+v", users[0])
+	}
+	if users[1].Id != 2 || users[1].Home.City.Valid || users[1].Settings != nil {
+		t.Errorf("wrong second user: %+v", users[1])
+	}
+
+	db = openFakeDB(t, []string{"id", "password"}, []driver.Value{int64(1), "secret"})
+	defer db.Close()
+	var plain []user
+	err = (&Selector{}).Select("user").QueryStructs(ctx, db, &plain)
+	compareError(t, ErrNotStruct, err)
+
+	err = (&Selector{}).Select("user").QueryStructs(ctx, db, plain)
+	compareError(t, ErrNotStruct, err)
+}
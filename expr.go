@@ -0,0 +1,175 @@
+package dbselector
+
+import "fmt"
+
+// условие BETWEEN
+type betweenClause struct {
+	field string
+	from  interface{}
+	to    interface{}
+}
+
+// условие IS NULL / IS NOT NULL
+type nullClause struct {
+	field string
+	not   bool
+}
+
+// отрицание условия
+type notCondition struct {
+	cond Condition
+}
+
+// Условие field = bind
+func Eq(field string, bind interface{}) Condition {
+	return clause{field, "=", bind}
+}
+
+// Условие field <> bind
+func NotEq(field string, bind interface{}) Condition {
+	return clause{field, "<>", bind}
+}
+
+// Условие field > bind
+func Gt(field string, bind interface{}) Condition {
+	return clause{field, ">", bind}
+}
+
+// Условие field >= bind
+func Gte(field string, bind interface{}) Condition {
+	return clause{field, ">=", bind}
+}
+
+// Условие field < bind
+func Lt(field string, bind interface{}) Condition {
+	return clause{field, "<", bind}
+}
+
+// Условие field <= bind
+func Lte(field string, bind interface{}) Condition {
+	return clause{field, "<=", bind}
+}
+
+// Условие field LIKE pattern
+func Like(field string, pattern interface{}) Condition {
+	return clause{field, "LIKE", pattern}
+}
+
+/*Условие field IN (binds). В отличие от WhereIn, пустой массив
+дает ложное условие, т.е. ни одна строка ему не соответствует.
+*/
+func In(field string, binds []interface{}) Condition {
+	if len(binds) == 0 {
+		return falseClause{}
+	}
+	return inClause{field, binds}
+}
+
+// Условие field BETWEEN from AND to
+func Between(field string, from interface{}, to interface{}) Condition {
+	return betweenClause{field: field, from: from, to: to}
+}
+
+// Условие field IS NULL
+func IsNull(field string) Condition {
+	return nullClause{field: field}
+}
+
+// Условие field IS NOT NULL
+func IsNotNull(field string) Condition {
+	return nullClause{field: field, not: true}
+}
+
+// Отрицание условия: NOT (cond)
+func Not(cond Condition) Condition {
+	return notCondition{cond: cond}
+}
+
+/*Группа условий, соединенных через OR. Пустые вложенные группы пропускаются,
+а пустая группа целиком не попадает в запрос.
+Пример использования:
+	selector := &Selector{}
+	selector.Select("user").WhereExpr(AnyOf(Eq("role", "admin"), AllOf(Gt("age", 18), IsNotNull("email"))))
+	тогда sql содержит: SELECT * FROM "user" WHERE ("role" = :role1 OR ("age" > :age2 AND "email" IS NOT NULL))
+*/
+func AnyOf(conds ...Condition) Condition {
+	return newConditionGroup(conjunctionOr, conds)
+}
+
+// Группа условий, соединенных через AND, см. AnyOf
+func AllOf(conds ...Condition) Condition {
+	return newConditionGroup(conjunctionAnd, conds)
+}
+
+func newConditionGroup(conjunction string, conds []Condition) *conditionGroup {
+	group := &conditionGroup{}
+	for _, cond := range conds {
+		group.items = append(group.items, conditionItem{conjunction: conjunction, cond: cond})
+	}
+	return group
+}
+
+/*Добавляет к sql запросу условие, построенное функциями Eq, Gt, In, Not, AnyOf и т.п.,
+через связку AND. Как и Where, первое условие попадает в секцию WHERE.
+Параметры:
+	cond - условие
+Результат:
+	ссылка Selector на самого себя
+Пример использования:
+	selector := &Selector{}
+	selector.Select("user").WhereExpr(Between("age", 18, 30)).OrExpr(Not(Like("email", "%@test.io")))
+*/
+func (s *Selector) WhereExpr(cond Condition) *Selector {
+	s.where.add(conjunctionAnd, cond)
+	return s
+}
+
+// Добавляет к sql запросу AND _условие_, см. WhereExpr
+func (s *Selector) AndExpr(cond Condition) *Selector {
+	s.where.add(conjunctionAnd, cond)
+	return s
+}
+
+// Добавляет к sql запросу OR _условие_, см. WhereExpr
+func (s *Selector) OrExpr(cond Condition) *Selector {
+	s.where.add(conjunctionOr, cond)
+	return s
+}
+
+func (c betweenClause) conditionSql(s *Selector, raw bool, binds map[string]interface{}) (string, error) {
+	field, err := s.conditionField(c.field)
+	if err != nil {
+		return "", err
+	}
+	from, err := s.bindValue(c.field, c.from, raw, binds)
+	if err != nil {
+		return "", err
+	}
+	to, err := s.bindValue(c.field, c.to, raw, binds)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%v BETWEEN %v AND %v", field, from, to), nil
+}
+
+func (c nullClause) conditionSql(s *Selector, raw bool, binds map[string]interface{}) (string, error) {
+	field, err := s.conditionField(c.field)
+	if err != nil {
+		return "", err
+	}
+	if c.not {
+		return fmt.Sprintf("%v IS NOT NULL", field), nil
+	}
+	return fmt.Sprintf("%v IS NULL", field), nil
+}
+
+func (c notCondition) conditionSql(s *Selector, raw bool, binds map[string]interface{}) (string, error) {
+	sql, err := c.cond.conditionSql(s, raw, binds)
+	if err != nil || sql == "" {
+		return "", err
+	}
+	if _, ok := c.cond.(*conditionGroup); ok {
+		return "NOT " + sql, nil
+	}
+	return "NOT (" + sql + ")", nil
+}
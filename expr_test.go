@@ -0,0 +1,53 @@
+package dbselector
+
+import "testing"
+
+func TestSelectorWhereExpr(t *testing.T) {
+
+	sel := &Selector{}
+	sel.Select("user").
+		WhereExpr(AnyOf(Eq("role", "admin"), AllOf(Gte("age", 18), Lt("age", 65), IsNotNull("email")))).
+		AndExpr(Not(Like("email", "%@test.io"))).
+		OrExpr(AllOf(Between("created", 10, 20), IsNull("deleted"), NotEq("id", Ident("parent_id"))))
+	sql, binds := sel.Sql()
+
+	gageSql := "SELECT * FROM \"user\" WHERE (\"role\" = :role1 OR (\"age\" >= :age2 AND \"age\" < :age3 AND \"email\" IS NOT NULL)) " +
+		"AND NOT (\"email\" LIKE :email4) " +
+		"OR (\"created\" BETWEEN :created5 AND :created6 AND \"deleted\" IS NULL AND \"id\" <> \"parent_id\")"
+	compareSql(t, gageSql, sql)
+
+	gage := map[string]interface{}{
+		"role1":    "admin",
+		"age2":     18,
+		"age3":     65,
+		"email4":   "%@test.io",
+		"created5": 10,
+		"created6": 20,
+	}
+	compareBinds(t, binds, gage)
+}
+
+func TestSelectorWhereExprIn(t *testing.T) {
+
+	sel := &Selector{}
+	sel.Select("user").Where("active", "=", true).
+		AndExpr(AnyOf(In("id", []interface{}{1, 2}), Gt("rating", 4))).
+		AndExpr(Not(AnyOf(In("role", []interface{}{}), AllOf())))
+	sql, binds := sel.RawSql()
+
+	gageSql := "SELECT * FROM \"user\" WHERE \"active\" = $1 AND (\"id\" IN ($2,$3) OR \"rating\" > $4) AND NOT (false)"
+	compareSql(t, gageSql, sql)
+	compareBinds(t, binds, []interface{}{true, 1, 2, 4})
+}
+
+func TestSelectorWhereExprMixedWithBrackets(t *testing.T) {
+
+	sel := &Selector{}
+	sel.Select("user").OpenBracket().Or("name", "=", "Vova").OrExpr(Gt("age", 30)).CloseBracket().
+		AndExpr(AllOf())
+	sql, binds := sel.RawSql()
+
+	gageSql := "SELECT * FROM \"user\" WHERE (\"name\" = $1 OR \"age\" > $2)"
+	compareSql(t, gageSql, sql)
+	compareBinds(t, binds, []interface{}{"Vova", 30})
+}
@@ -0,0 +1,118 @@
+package dbselector
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// вызов агрегатной функции над полем: sum(amount), count(*), count(DISTINCT user_id)
+var aggregateRegexp = regexp.MustCompile(`(?i)^\s*(count|sum|avg|min|max)\s*\(\s*(distinct\s+)?([^()]+?)\s*\)\s*$`)
+
+/*Задает список полей для секции GROUP BY
+Параметры:
+	fields - имена полей в таблице БД
+Результат:
+	ссылка Selector на самого себя
+Пример использования:
+	selector := &Selector{}
+	selector.Select("order").Columns("user_id", "sum(amount)").GroupBy("user_id")
+	тогда sql содержит: SELECT "user_id", sum("amount") FROM "order" GROUP BY "user_id"
+*/
+func (s *Selector) GroupBy(fields ...string) *Selector {
+	s.groupBy = append(s.groupBy, fields...)
+	return s
+}
+
+/*Добавляет к sql запросу HAVING _условие_
+Вместо имени поля можно указать агрегатную функцию над полем: count, sum, avg, min, max,
+в том числе count(*) и count(DISTINCT поле). Значения передаются через биндинг так же, как в Where.
+Параметры:
+	field - имя поля или агрегатная функция
+	operation - опреация используемая для срвнения =, <, >, LIKE и т.п.
+	bind - данные для подстановки
+Результат:
+	ссылка Selector на самого себя
+Пример использования:
+	selector := &Selector{}
+	selector.Select("order").Columns("user_id").GroupBy("user_id").
+		Having("sum(amount)", ">", 1000).OrHaving("count(DISTINCT shop_id)", ">=", 3)
+	тогда sql содержит: SELECT "user_id" FROM "order" GROUP BY "user_id"
+		HAVING sum("amount") > :sum_amount1 OR count(DISTINCT "shop_id") >= :count_DISTINCT_shop_id2
+*/
+func (s *Selector) Having(field string, operation string, bind interface{}) *Selector {
+	s.having.add(conjunctionAnd, clause{field, operation, bind})
+	return s
+}
+
+// Добавляет к sql запросу AND _условие_ в секции HAVING, см. Having
+func (s *Selector) AndHaving(field string, operation string, bind interface{}) *Selector {
+	s.having.add(conjunctionAnd, clause{field, operation, bind})
+	return s
+}
+
+// Добавляет к sql запросу OR _условие_ в секции HAVING, см. Having
+func (s *Selector) OrHaving(field string, operation string, bind interface{}) *Selector {
+	s.having.add(conjunctionOr, clause{field, operation, bind})
+	return s
+}
+
+//формирует секцию GROUP BY запроса
+func (s *Selector) groupBySql() (string, error) {
+	if len(s.groupBy) == 0 {
+		return "", nil
+	}
+
+	fields := make([]string, 0, len(s.groupBy))
+	for _, field := range s.groupBy {
+		sql, err := s.fieldSql(field)
+		if err != nil {
+			return "", err
+		}
+		fields = append(fields, sql)
+	}
+	return " GROUP BY " + strings.Join(fields, ", "), nil
+}
+
+//возвращает поле (допускается звездочка) или агрегатную функцию над полем
+//с именем поля в кавычках
+func (s *Selector) fieldSql(field string) (string, error) {
+	if sql, ok, err := s.aggregateSql(field); ok || err != nil {
+		return sql, err
+	}
+	return s.columnIdentifier(field)
+}
+
+//возвращает поле или агрегатную функцию над полем для условия с именем поля в кавычках
+func (s *Selector) conditionField(field string) (string, error) {
+	if sql, ok, err := s.aggregateSql(field); ok || err != nil {
+		return sql, err
+	}
+	return s.identifier(field)
+}
+
+//если field является вызовом агрегатной функции, возвращает ее с именем поля в кавычках
+func (s *Selector) aggregateSql(field string) (string, bool, error) {
+	m := aggregateRegexp.FindStringSubmatch(field)
+	if m == nil {
+		return "", false, nil
+	}
+
+	function := strings.ToLower(m[1])
+	argument := m[3]
+	if argument == "*" {
+		if function != "count" || m[2] != "" {
+			return "", true, fmt.Errorf("%w: %q", ErrInvalidIdentifier, field)
+		}
+	} else {
+		quoted, err := s.identifier(argument)
+		if err != nil {
+			return "", true, err
+		}
+		argument = quoted
+	}
+	if m[2] != "" {
+		argument = "DISTINCT " + argument
+	}
+	return function + "(" + argument + ")", true, nil
+}
@@ -0,0 +1,39 @@
+package dbselector
+
+import "testing"
+
+func TestSelectorGroupByHaving(t *testing.T) {
+
+	sel := &Selector{}
+	sel.Select("order").Columns("user_id", "sum(amount)").ColumnAs("count(DISTINCT shop_id)", "shops").
+		Where("status", "=", "paid").GroupBy("user_id").
+		Having("sum(amount)", ">", 1000).OrHaving("count(DISTINCT shop_id)", ">=", 3).
+		AndHaving("max(amount)", "<", 500).OrderBy("user_id")
+	sql, binds := sel.Sql()
+
+	gageSql := "SELECT \"user_id\", sum(\"amount\"), count(DISTINCT \"shop_id\") AS \"shops\" FROM \"order\" " +
+		"WHERE \"status\" = :status1 GROUP BY \"user_id\" " +
+		"HAVING sum(\"amount\") > :sum_amount2 OR count(DISTINCT \"shop_id\") >= :count_DISTINCT_shop_id3 " +
+		"AND max(\"amount\") < :max_amount4 ORDER BY user_id"
+	compareSql(t, gageSql, sql)
+
+	gage := map[string]interface{}{
+		"status1":                 "paid",
+		"sum_amount2":             1000,
+		"count_DISTINCT_shop_id3": 3,
+		"max_amount4":             500,
+	}
+	compareBinds(t, binds, gage)
+}
+
+func TestSelectorHavingCountAll(t *testing.T) {
+
+	sel := &Selector{}
+	sel.Select("post").Columns("author_id", "avg(rating)").GroupBy("author_id").
+		Having("count(*)", ">", 10)
+	sql, binds := sel.RawSql()
+
+	gageSql := "SELECT \"author_id\", avg(\"rating\") FROM \"post\" GROUP BY \"author_id\" HAVING count(*) > $1"
+	compareSql(t, gageSql, sql)
+	compareBinds(t, binds, []interface{}{10})
+}
@@ -0,0 +1,57 @@
+package dbselector
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// допустимая часть идентификатора: буквы, цифры, подчеркивание и $, не начинается с цифры
+var identifierPartRegexp = regexp.MustCompile(`^[\p{L}_][\p{L}\p{N}_$]*$`)
+
+//проверяет имя таблицы или поля вида name, table.column или schema.table.column,
+//если allowStar - последней частью может быть звездочка (*, table.*)
+func validateIdentifier(name string, allowStar bool) error {
+	parts := strings.Split(name, ".")
+	if len(parts) > 3 {
+		return fmt.Errorf("%w: %q", ErrInvalidIdentifier, name)
+	}
+	for i, part := range parts {
+		if part == "*" && allowStar && i == len(parts)-1 {
+			continue
+		}
+		if !identifierPartRegexp.MatchString(part) {
+			return fmt.Errorf("%w: %q", ErrInvalidIdentifier, name)
+		}
+	}
+	return nil
+}
+
+//проверяет имя таблицы, поля или псевдонима и возвращает его в кавычках диалекта
+func (s *Selector) identifier(name string) (string, error) {
+	if err := validateIdentifier(name, false); err != nil {
+		return "", err
+	}
+	return s.dialect.quoteIdentifier(name), nil
+}
+
+//то же, что identifier, но допускает звездочку в качестве имени поля (*, table.*)
+func (s *Selector) columnIdentifier(name string) (string, error) {
+	if err := validateIdentifier(name, true); err != nil {
+		return "", err
+	}
+	return s.dialect.quoteIdentifier(name), nil
+}
+
+//проверяет список имен полей и возвращает их в кавычках через запятую
+func (s *Selector) identifierList(names []string) (string, error) {
+	quoted := make([]string, 0, len(names))
+	for _, name := range names {
+		q, err := s.identifier(name)
+		if err != nil {
+			return "", err
+		}
+		quoted = append(quoted, q)
+	}
+	return strings.Join(quoted, ", "), nil
+}
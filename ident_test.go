@@ -0,0 +1,53 @@
+package dbselector
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestIdentifierQuoting(t *testing.T) {
+
+	sel := NewSelector(DIALECT_MYSQL)
+	sel.Select("shop.user").As("u").Columns("u.*", "u.имя").Where("shop.u.id", "=", 1)
+	sql, _, err := sel.BuildRaw()
+	compareError(t, nil, err)
+	compareSql(t, "SELECT `u`.*, `u`.`имя` FROM `shop`.`user` AS `u` WHERE `shop`.`u`.`id` = ?", sql)
+
+	compareSql(t, "\"a\"\"b\".\"c\"", DIALECT_POSTGRESS.quoteIdentifier("a\"b.c"))
+	compareSql(t, "`a``b`", DIALECT_MYSQL.quoteIdentifier("a`b"))
+}
+
+func TestIdentifierValidation(t *testing.T) {
+
+	tests := []struct {
+		name     string
+		selector *Selector
+	}{
+		{"table", (&Selector{}).Select("user\"; DROP TABLE user; --")},
+		{"alias", (&Selector{}).Select("user").As("u u")},
+		{"column", (&Selector{}).Select("user").Columns("id, password")},
+		{"column alias", (&Selector{}).Select("user").ColumnAs("id", "x\"")},
+		{"star in where", (&Selector{}).Select("user").Where("*", "=", 1)},
+		{"where field", (&Selector{}).Select("user").Where("1=1 OR id", "=", 1)},
+		{"ident bind", (&Selector{}).Select("user").Where("id", "=", Ident("parent_id)"))},
+		{"too many parts", (&Selector{}).Select("user").Where("a.b.c.d", "=", 1)},
+		{"empty part", (&Selector{}).Select("user").Where("u..id", "=", 1)},
+		{"aggregate", (&Selector{}).Select("user").GroupBy("city").Having("sum(*)", ">", 1)},
+		{"aggregate field", (&Selector{}).Select("user").Columns("max(id) FROM x; --)")},
+		{"group by", (&Selector{}).Select("user").GroupBy("city; --")},
+		{"set", (&Selector{}).Update("user").Set("name = 'x', role", "admin")},
+		{"returning", (&Selector{}).Delete("user").Returning("id; --")},
+		{"join", (&Selector{}).Select("user").Join("post p", "").On("p.id", "=", 1)},
+		{"distinct on", (&Selector{}).Select("user").DistinctOn("1)")},
+		{"insert column", (&Selector{}).Insert("user").Values([]interface{}{struct {
+			Name string `db:"name) VALUES ('x'); --"`
+		}{}})},
+	}
+
+	for _, test := range tests {
+		_, _, err := test.selector.Build()
+		if !errors.Is(err, ErrInvalidIdentifier) {
+			t.Errorf("%s: ожидалась ошибка %v, получено %v", test.name, ErrInvalidIdentifier, err)
+		}
+	}
+}
@@ -0,0 +1,139 @@
+package dbselector
+
+import "fmt"
+
+// виды соединения таблиц
+const (
+	JOIN_INNER = "INNER JOIN"
+	JOIN_LEFT  = "LEFT JOIN"
+	JOIN_RIGHT = "RIGHT JOIN"
+	JOIN_FULL  = "FULL JOIN"
+	JOIN_CROSS = "CROSS JOIN"
+)
+
+// присоединяемая таблица
+type join struct {
+	kind      string        //вид соединения
+	tableName string        //имя таблицы
+	alias     string        //псевдоним таблицы
+	on        conditionTree //дерево условий секции ON
+}
+
+/*Задает псевдоним для основной таблицы запроса
+Параметры:
+	alias - псевдоним таблицы
+Результат:
+	ссылка Selector на самого себя
+Пример использования:
+	selector := &Selector{}
+	selector.Select("user").As("u")
+*/
+func (s *Selector) As(alias string) *Selector {
+	s.alias = alias
+	return s
+}
+
+/*Добавляет к sql запросу INNER JOIN таблицы. Условия соединения задаются
+последующими вызовами On, AndOn и OrOn.
+Параметры:
+	tableName - имя присоединяемой таблицы
+	alias - псевдоним таблицы, если пустая строка - псевдоним не указывается
+Результат:
+	ссылка Selector на самого себя
+Пример использования:
+	selector := &Selector{}
+	selector.Select("user").As("u").Join("post", "p").On("p.user_id", "=", Ident("u.id"))
+	тогда sql содержит: SELECT * FROM "user" AS "u" INNER JOIN "post" AS "p" ON "p"."user_id" = "u"."id"
+*/
+func (s *Selector) Join(tableName string, alias string) *Selector {
+	return s.addJoin(JOIN_INNER, tableName, alias)
+}
+
+// Добавляет к sql запросу LEFT JOIN таблицы, см. Join
+func (s *Selector) LeftJoin(tableName string, alias string) *Selector {
+	return s.addJoin(JOIN_LEFT, tableName, alias)
+}
+
+// Добавляет к sql запросу RIGHT JOIN таблицы, см. Join
+func (s *Selector) RightJoin(tableName string, alias string) *Selector {
+	return s.addJoin(JOIN_RIGHT, tableName, alias)
+}
+
+// Добавляет к sql запросу FULL JOIN таблицы, см. Join
+func (s *Selector) FullJoin(tableName string, alias string) *Selector {
+	return s.addJoin(JOIN_FULL, tableName, alias)
+}
+
+// Добавляет к sql запросу CROSS JOIN таблицы, условия соединения для него не задаются
+func (s *Selector) CrossJoin(tableName string, alias string) *Selector {
+	return s.addJoin(JOIN_CROSS, tableName, alias)
+}
+
+func (s *Selector) addJoin(kind string, tableName string, alias string) *Selector {
+	s.joins = append(s.joins, join{kind: kind, tableName: tableName, alias: alias})
+	return s
+}
+
+/*Добавляет условие соединения ON к последней присоединенной таблице.
+Значения передаются через биндинг так же, как в Where, для сравнения
+с полем другой таблицы значение нужно передать как Ident.
+Параметры:
+	field - имя поля в таблице БД
+	operation - опреация используемая для срвнения =, <, >, LIKE и т.п.
+	bind - данные для подстановки или Ident
+Результат:
+	ссылка Selector на самого себя
+Пример использования:
+	selector := &Selector{}
+	selector.Select("user").As("u").LeftJoin("post", "p").
+		On("p.user_id", "=", Ident("u.id")).AndOn("p.active", "=", true)
+*/
+func (s *Selector) On(field string, operation string, bind interface{}) *Selector {
+	return s.addJoinClause(conjunctionAnd, clause{field, operation, bind})
+}
+
+// Добавляет к условию соединения AND _условие_, см. On
+func (s *Selector) AndOn(field string, operation string, bind interface{}) *Selector {
+	return s.addJoinClause(conjunctionAnd, clause{field, operation, bind})
+}
+
+// Добавляет к условию соединения OR _условие_, см. On
+func (s *Selector) OrOn(field string, operation string, bind interface{}) *Selector {
+	return s.addJoinClause(conjunctionOr, clause{field, operation, bind})
+}
+
+func (s *Selector) addJoinClause(conjunction string, cond Condition) *Selector {
+	if len(s.joins) == 0 {
+		s.setError(ErrNoJoin)
+		return s
+	}
+	j := &s.joins[len(s.joins)-1]
+	j.on.add(conjunction, cond)
+	return s
+}
+
+//формирует секцию JOIN для запроса и биндинг
+func (s *Selector) joinsSql(raw bool) (string, map[string]interface{}, error) {
+	binds := make(map[string]interface{})
+	resultSQL := ""
+	for _, j := range s.joins {
+		if j.kind == JOIN_FULL && s.dialect == DIALECT_MYSQL {
+			return "", binds, fmt.Errorf("%w: %s в %v", ErrUnsupported, j.kind, s.dialect)
+		}
+		if (j.kind == JOIN_CROSS) != j.on.empty() {
+			return "", binds, fmt.Errorf("%w: %s %s", ErrJoinCondition, j.kind, j.tableName)
+		}
+
+		tableName, err := s.tableSql(j.tableName, j.alias)
+		if err != nil {
+			return "", binds, err
+		}
+		resultSQL += fmt.Sprintf(" %s %s", j.kind, tableName)
+		onSql, err := j.on.sql("ON", s, raw, binds)
+		if err != nil {
+			return "", binds, err
+		}
+		resultSQL += onSql
+	}
+	return resultSQL, binds, nil
+}
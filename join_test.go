@@ -0,0 +1,52 @@
+package dbselector
+
+import "testing"
+
+func TestSelectorJoin(t *testing.T) {
+
+	sel := &Selector{}
+	sel.Select("user").As("u").Columns("u.name", "p.title").
+		Join("post", "p").On("p.user_id", "=", Ident("u.id")).AndOn("p.active", "=", true).
+		Where("u.name", "=", "Vova")
+	sql, binds := sel.Sql()
+
+	gageSql := "SELECT \"u\".\"name\", \"p\".\"title\" FROM \"user\" AS \"u\" " +
+		"INNER JOIN \"post\" AS \"p\" ON \"p\".\"user_id\" = \"u\".\"id\" AND \"p\".\"active\" = :p_active1 " +
+		"WHERE \"u\".\"name\" = :u_name2"
+	compareSql(t, gageSql, sql)
+
+	gage := map[string]interface{}{"p_active1": true, "u_name2": "Vova"}
+	compareBinds(t, binds, gage)
+}
+
+func TestSelectorJoinKinds(t *testing.T) {
+
+	sel := &Selector{}
+	sel.Select("user").As("u").
+		LeftJoin("post", "p").On("p.user_id", "=", Ident("u.id")).
+		RightJoin("city", "").On("city.id", "=", Ident("u.city_id")).OrOn("city.id", "=", 0).
+		FullJoin("team", "t").On("t.id", "=", Ident("u.team_id")).
+		CrossJoin("settings", "s")
+	sql, binds := sel.RawSql()
+
+	gageSql := "SELECT * FROM \"user\" AS \"u\" " +
+		"LEFT JOIN \"post\" AS \"p\" ON \"p\".\"user_id\" = \"u\".\"id\" " +
+		"RIGHT JOIN \"city\" ON \"city\".\"id\" = \"u\".\"city_id\" OR \"city\".\"id\" = $1 " +
+		"FULL JOIN \"team\" AS \"t\" ON \"t\".\"id\" = \"u\".\"team_id\" " +
+		"CROSS JOIN \"settings\" AS \"s\""
+	compareSql(t, gageSql, sql)
+	compareBinds(t, binds, []interface{}{0})
+}
+
+func TestSelectorJoinRawNumbering(t *testing.T) {
+
+	sel := &Selector{}
+	sel.Select("user").As("u").Join("post", "p").On("p.user_id", "=", Ident("u.id")).
+		AndOn("p.rating", ">", 5).Where("u.age", ">", 18)
+	sql, binds := sel.RawSql()
+
+	gageSql := "SELECT * FROM \"user\" AS \"u\" INNER JOIN \"post\" AS \"p\" " +
+		"ON \"p\".\"user_id\" = \"u\".\"id\" AND \"p\".\"rating\" > $1 WHERE \"u\".\"age\" > $2"
+	compareSql(t, gageSql, sql)
+	compareBinds(t, binds, []interface{}{5, 18})
+}
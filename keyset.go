@@ -0,0 +1,242 @@
+package dbselector
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/gob"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// позиция постраничной выборки по ключу: значения полей сортировки последней строки
+type keyset struct {
+	values []interface{} //значения полей OrderBind последней строки предыдущей страницы
+	orders []string      //сортировка, для которой выдан курсор, nil если позиция задана через After
+}
+
+// условие, отбирающее строки после позиции keyset в порядке сортировки запроса
+type keysetClause struct {
+	keyset *keyset
+}
+
+// содержимое курсора
+type cursorPayload struct {
+	Orders []string
+	Values []interface{}
+}
+
+func init() {
+	gob.Register(time.Time{})
+}
+
+/*Включает постраничную выборку по ключу (keyset pagination) вместо OFFSET: запрос
+возвращает строки, следующие в порядке OrderBind за строкой с указанными значениями
+полей сортировки. Значения передаются в порядке вызовов OrderBind, поля сортировки
+не должны содержать NULL, а их набор должен однозначно определять строку, например
+заканчиваться первичным ключом.
+Параметры:
+	values - значения полей сортировки последней строки предыдущей страницы
+Результат:
+	ссылка Selector на самого себя
+Пример использования:
+	selector := &Selector{}
+	selector.Select("post").OrderBind("created", "desc").OrderBind("id", "desc").After(last.Created, last.Id).Limit(20)
+	тогда sql содержит: SELECT * FROM "post" WHERE ("created", "id") < (:created1, :id2) ORDER BY "created" desc, "id" desc LIMIT 20
+	при разных направлениях сортировки условие раскрывается:
+		WHERE ("created" < :created1 OR ("created" = :created2 AND "id" > :id3))
+*/
+func (s *Selector) After(values ...interface{}) *Selector {
+	s.keyset = &keyset{values: values}
+	return s
+}
+
+/*Формирует курсор - непрозрачную строку с позицией постраничной выборки, которую
+можно передать клиенту и затем принять обратно через AfterCursor. Курсор подписывается
+HMAC-SHA256 с ключом secret, поэтому подделка или изменение курсора обнаруживается,
+но его содержимое не шифруется. В курсор также записывается сортировка запроса,
+курсор не принимается запросом с другой сортировкой.
+Параметры:
+	secret - ключ подписи
+	values - значения полей сортировки последней строки страницы
+Результат:
+	1. курсор
+	2. ошибка или nil
+Пример использования:
+	token, err := selector.Cursor(secret, last.Created, last.Id)
+*/
+func (s *Selector) Cursor(secret []byte, values ...interface{}) (string, error) {
+	if len(secret) == 0 {
+		return "", fmt.Errorf("%w: пустой ключ подписи", ErrInvalidCursor)
+	}
+	if len(values) != len(s.orders) {
+		return "", fmt.Errorf("%w: передано %d значений для %d полей сортировки", ErrKeyset, len(values), len(s.orders))
+	}
+
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(cursorPayload{Orders: s.orderNames(), Values: values}); err != nil {
+		return "", fmt.Errorf("%w: %v", ErrInvalidCursor, err)
+	}
+	data := buf.Bytes()
+	return base64.RawURLEncoding.EncodeToString(append(data, cursorMac(secret, data)...)), nil
+}
+
+/*Включает постраничную выборку по ключу с позицией из курсора, полученного от Cursor.
+Неверный или подписанный другим ключом курсор, а также курсор, выданный для другой
+сортировки, приводят к ошибке ErrInvalidCursor. Пустой курсор означает первую страницу.
+Параметры:
+	secret - ключ подписи
+	token - курсор
+Результат:
+	ссылка Selector на самого себя
+Пример использования:
+	selector := &Selector{}
+	selector.Select("post").OrderBind("created", "desc").OrderBind("id", "desc").AfterCursor(secret, r.FormValue("cursor")).Limit(20)
+*/
+func (s *Selector) AfterCursor(secret []byte, token string) *Selector {
+	if token == "" {
+		return s
+	}
+	if len(secret) == 0 {
+		s.setError(fmt.Errorf("%w: пустой ключ подписи", ErrInvalidCursor))
+		return s
+	}
+
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil || len(raw) < sha256.Size {
+		s.setError(ErrInvalidCursor)
+		return s
+	}
+	data, mac := raw[:len(raw)-sha256.Size], raw[len(raw)-sha256.Size:]
+	if !hmac.Equal(mac, cursorMac(secret, data)) {
+		s.setError(ErrInvalidCursor)
+		return s
+	}
+
+	var payload cursorPayload
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&payload); err != nil {
+		s.setError(fmt.Errorf("%w: %v", ErrInvalidCursor, err))
+		return s
+	}
+	if payload.Orders == nil {
+		payload.Orders = []string{}
+	}
+	s.keyset = &keyset{values: payload.Values, orders: payload.Orders}
+	return s
+}
+
+//вычисляет подпись данных курсора
+func cursorMac(secret []byte, data []byte) []byte {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(data)
+	return mac.Sum(nil)
+}
+
+//возвращает сортировку запроса в виде строк "поле направление"
+func (s *Selector) orderNames() []string {
+	names := make([]string, 0, len(s.orders))
+	for _, o := range s.orders {
+		names = append(names, strings.TrimSpace(o.field+" "+o.dir+" "+o.nulls))
+	}
+	return names
+}
+
+func (c keysetClause) conditionSql(s *Selector, raw bool, binds map[string]interface{}) (string, error) {
+	k := c.keyset
+	if s.orderBy != "" || len(s.orders) == 0 {
+		return "", fmt.Errorf("%w: сортировка должна быть задана через OrderBind", ErrKeyset)
+	}
+	if k.orders != nil && strings.Join(k.orders, ",") != strings.Join(s.orderNames(), ",") {
+		return "", fmt.Errorf("%w: курсор выдан для другой сортировки", ErrInvalidCursor)
+	}
+	if len(k.values) != len(s.orders) {
+		return "", fmt.Errorf("%w: передано %d значений для %d полей сортировки", ErrKeyset, len(k.values), len(s.orders))
+	}
+	// проверка полей по списку SortableColumns
+	if _, err := s.ordersSql(s.orders); err != nil {
+		return "", err
+	}
+
+	fields := make([]string, 0, len(s.orders))
+	sameDir := true
+	for _, o := range s.orders {
+		if o.nulls != "" {
+			return "", fmt.Errorf("%w: NULLS FIRST/LAST", ErrKeyset)
+		}
+		field, err := s.identifier(o.field)
+		if err != nil {
+			return "", err
+		}
+		fields = append(fields, field)
+		sameDir = sameDir && o.dir == s.orders[0].dir
+	}
+
+	// при одинаковом направлении сортировки достаточно сравнения кортежей
+	if sameDir {
+		placeholders := make([]string, 0, len(k.values))
+		for i, value := range k.values {
+			ph, err := s.bindValue(s.orders[i].field, value, raw, binds)
+			if err != nil {
+				return "", err
+			}
+			placeholders = append(placeholders, ph)
+		}
+		op := keysetOperator(s.orders[0].dir)
+		if len(fields) == 1 {
+			return fmt.Sprintf("%v %v %v", fields[0], op, placeholders[0]), nil
+		}
+		return fmt.Sprintf("(%v) %v (%v)", strings.Join(fields, ", "), op, strings.Join(placeholders, ", ")), nil
+	}
+
+	// иначе (a > $1 OR (a = $2 AND b < $3) OR ...)
+	alternatives := make([]string, 0, len(fields))
+	for i := range fields {
+		parts := make([]string, 0, i+1)
+		for j := 0; j <= i; j++ {
+			op := "="
+			if j == i {
+				op = keysetOperator(s.orders[j].dir)
+			}
+			ph, err := s.bindValue(s.orders[j].field, k.values[j], raw, binds)
+			if err != nil {
+				return "", err
+			}
+			parts = append(parts, fmt.Sprintf("%v %v %v", fields[j], op, ph))
+		}
+		if len(parts) == 1 {
+			alternatives = append(alternatives, parts[0])
+		} else {
+			alternatives = append(alternatives, "("+strings.Join(parts, " AND ")+")")
+		}
+	}
+	return "(" + strings.Join(alternatives, " OR ") + ")", nil
+}
+
+//возвращает оператор сравнения для строк, следующих за позицией при направлении сортировки dir
+func keysetOperator(dir string) string {
+	if dir == "desc" {
+		return "<"
+	}
+	return ">"
+}
+
+//возвращает дерево условий WHERE, дополненное условием постраничной выборки по ключу
+func (s *Selector) whereTree() *conditionTree {
+	if s.keyset == nil {
+		return &s.where
+	}
+
+	// дерево строится на копии, чтобы формирование запроса не изменяло селектор
+	where := s.where.clone()
+	tree := &conditionTree{opened: where.opened, err: where.err}
+	if where.root.hasOr() {
+		// условия, соединенные через OR, заключаются в скобки
+		tree.root.items = []conditionItem{{conjunction: conjunctionAnd, cond: &where.root}}
+	} else {
+		tree.root.items = where.root.items
+	}
+	tree.root.items = append(tree.root.items, conditionItem{conjunction: conjunctionAnd, cond: keysetClause{keyset: s.keyset}})
+	return tree
+}
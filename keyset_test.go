@@ -0,0 +1,151 @@
+package dbselector
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestKeysetAfter(t *testing.T) {
+
+	created := time.Date(2021, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	sel := &Selector{}
+	sel.Select("post").Where("published", "=", true).
+		OrderBind("created", "desc").OrderBind("id", "desc").After(created, 42).Limit(20)
+	sql, binds, err := sel.Build()
+	compareError(t, nil, err)
+
+	gageSql := "SELECT * FROM \"post\" WHERE \"published\" = :published1 AND (\"created\", \"id\") < (:created2, :id3) " +
+		"ORDER BY \"created\" desc, \"id\" desc LIMIT 20"
+	compareSql(t, gageSql, sql)
+	compareBinds(t, binds, map[string]interface{}{"published1": true, "created2": created, "id3": 42})
+
+	sel = NewSelector(DIALECT_MYSQL)
+	sel.Select("post").Where("author", "=", 1).Or("author", "=", 2).
+		OrderBind("rating", "desc").OrderBind("id", "asc").After(5, 42)
+	rawSql, rawBinds, err := sel.BuildRaw()
+	compareError(t, nil, err)
+
+	gageSql = "SELECT * FROM `post` WHERE (`author` = ? OR `author` = ?) " +
+		"AND (`rating` < ? OR (`rating` = ? AND `id` > ?)) ORDER BY `rating` desc, `id` asc"
+	compareSql(t, gageSql, rawSql)
+	compareBinds(t, rawBinds, []interface{}{1, 2, 5, 5, 42})
+
+	sel = &Selector{}
+	sel.Select("post").OrderBind("id", "asc").After(42)
+	sql, _, err = sel.BuildRaw()
+	compareError(t, nil, err)
+	compareSql(t, "SELECT * FROM \"post\" WHERE \"id\" > $1 ORDER BY \"id\" asc", sql)
+}
+
+func TestKeysetBuildTwice(t *testing.T) {
+
+	sel := &Selector{}
+	sel.Select("post").OpenBracket().Where("a", "=", 1).OrderBind("id", "asc").After(42)
+	_, _, err := sel.Build()
+	compareError(t, ErrUnbalancedBrackets, err)
+	_, _, err = sel.Build()
+	compareError(t, ErrUnbalancedBrackets, err)
+
+	sel.CloseBracket()
+	sql, _, err := sel.Build()
+	compareError(t, nil, err)
+	compareSql(t, "SELECT * FROM \"post\" WHERE (\"a\" = :a1) AND \"id\" > :id2 ORDER BY \"id\" asc", sql)
+
+	sql, _, err = sel.Build()
+	compareError(t, nil, err)
+	compareSql(t, "SELECT * FROM \"post\" WHERE (\"a\" = :a1) AND \"id\" > :id2 ORDER BY \"id\" asc", sql)
+}
+
+func TestKeysetErrors(t *testing.T) {
+
+	sel := &Selector{}
+	sel.Select("post").OrderBind("id", "asc").After(1, 2)
+	_, _, err := sel.Build()
+	compareError(t, ErrKeyset, err)
+
+	sel = &Selector{}
+	sel.Select("post").OrderBy("id").After(1)
+	_, _, err = sel.Build()
+	compareError(t, ErrKeyset, err)
+
+	sel = &Selector{}
+	sel.Select("post").OrderBind("rating", "desc nulls last").After(1)
+	_, _, err = sel.Build()
+	compareError(t, ErrKeyset, err)
+
+	sel = &Selector{}
+	sel.Select("post").SortableColumns("id").OrderBind("secret", "asc").After(1)
+	_, _, err = sel.Build()
+	compareError(t, ErrColumnNotAllowed, err)
+}
+
+func TestCursor(t *testing.T) {
+
+	secret := []byte("secret")
+	created := time.Date(2021, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	page := &Selector{}
+	page.Select("post").OrderBind("created", "desc").OrderBind("id", "asc")
+	token, err := page.Cursor(secret, created, int64(42))
+	compareError(t, nil, err)
+
+	sel := &Selector{}
+	sel.Select("post").OrderBind("created", "desc").OrderBind("id", "asc").AfterCursor(secret, token).Limit(20)
+	sql, binds, err := sel.BuildRaw()
+	compareError(t, nil, err)
+
+	gageSql := "SELECT * FROM \"post\" WHERE (\"created\" < $1 OR (\"created\" = $2 AND \"id\" > $3)) " +
+		"ORDER BY \"created\" desc, \"id\" asc LIMIT 20"
+	compareSql(t, gageSql, sql)
+	compareBinds(t, binds, []interface{}{created, created, int64(42)})
+
+	sel = &Selector{}
+	sel.Select("post").OrderBind("id", "asc").AfterCursor(secret, "").Limit(20)
+	sql, _, err = sel.BuildRaw()
+	compareError(t, nil, err)
+	compareSql(t, "SELECT * FROM \"post\" ORDER BY \"id\" asc LIMIT 20", sql)
+}
+
+func TestCursorErrors(t *testing.T) {
+
+	secret := []byte("secret")
+	page := &Selector{}
+	page.Select("post").OrderBind("id", "asc")
+	token, err := page.Cursor(secret, 42)
+	compareError(t, nil, err)
+
+	tampered := []byte(token)
+	tampered[len(tampered)/2] ^= 1
+
+	cases := []struct {
+		name   string
+		secret []byte
+		token  string
+		order  string
+	}{
+		{"wrong secret", []byte("other"), token, "asc"},
+		{"tampered", secret, string(tampered), "asc"},
+		{"garbage", secret, "not a cursor!", "asc"},
+		{"short", secret, "AAAA", "asc"},
+		{"other order", secret, token, "desc"},
+		{"empty secret", nil, token, "asc"},
+	}
+	for _, c := range cases {
+		sel := &Selector{}
+		sel.Select("post").OrderBind("id", c.order).AfterCursor(c.secret, c.token)
+		_, _, err := sel.Build()
+		if err == nil {
+			t.Errorf("%s: no error", c.name)
+			continue
+		}
+		compareError(t, ErrInvalidCursor, err)
+	}
+
+	_, err = page.Cursor(secret, 1, 2)
+	compareError(t, ErrKeyset, err)
+	if strings.Contains(token, "=") || strings.Contains(token, "/") {
+		t.Errorf("cursor is not url safe: %s", token)
+	}
+}
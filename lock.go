@@ -0,0 +1,127 @@
+package dbselector
+
+import (
+	"fmt"
+	"strings"
+)
+
+// режимы блокировки строк запроса SELECT
+const (
+	lockUpdate = "FOR UPDATE"
+	lockShare  = "FOR SHARE"
+)
+
+// поведение при встрече заблокированной строки
+const (
+	lockSkipLocked = "SKIP LOCKED"
+	lockNoWait     = "NOWAIT"
+)
+
+// блокировка строк, выбранных запросом SELECT
+type rowLock struct {
+	strength string   //FOR UPDATE или FOR SHARE
+	of       []string //таблицы, строки которых блокируются
+	wait     string   //SKIP LOCKED, NOWAIT или пустая строка - ожидание снятия блокировки
+}
+
+/*Блокирует выбранные строки для изменения: FOR UPDATE.
+Не поддерживается в SQLite, где блокируется вся база данных.
+Несовместим с Union, Count, Distinct, GroupBy, Having и ColumnOver: Build вернет ErrLockClause.
+Результат:
+	ссылка Selector на самого себя
+Пример использования:
+	selector := &Selector{}
+	selector.Select("job").Where("status", "=", "new").OrderBind("id", "asc").Limit(10).ForUpdate().SkipLocked()
+	тогда sql содержит: SELECT * FROM "job" WHERE "status" = :status1 ORDER BY "id" asc LIMIT 10 FOR UPDATE SKIP LOCKED
+*/
+func (s *Selector) ForUpdate() *Selector {
+	s.lockRows().strength = lockUpdate
+	return s
+}
+
+// Блокирует выбранные строки от изменения другими транзакциями: FOR SHARE, см. ForUpdate
+func (s *Selector) ForShare() *Selector {
+	s.lockRows().strength = lockShare
+	return s
+}
+
+// Пропускает строки, заблокированные другими транзакциями: SKIP LOCKED, см. ForUpdate
+func (s *Selector) SkipLocked() *Selector {
+	s.lockRows().wait = lockSkipLocked
+	return s
+}
+
+// Завершает запрос ошибкой вместо ожидания заблокированной строки: NOWAIT, см. ForUpdate
+func (s *Selector) NoWait() *Selector {
+	s.lockRows().wait = lockNoWait
+	return s
+}
+
+/*Ограничивает блокировку строками указанных таблиц запроса с соединениями
+Параметры:
+	tables - имена или псевдонимы таблиц
+Результат:
+	ссылка Selector на самого себя
+Пример использования:
+	selector := &Selector{}
+	selector.Select("job").As("j").Join("worker", "w").On("w.id", "=", Ident("j.worker_id")).ForUpdate().Of("j")
+	тогда sql содержит: ... FOR UPDATE OF "j"
+*/
+func (s *Selector) Of(tables ...string) *Selector {
+	lock := s.lockRows()
+	lock.of = append(lock.of, tables...)
+	return s
+}
+
+func (s *Selector) lockRows() *rowLock {
+	if s.lock == nil {
+		s.lock = &rowLock{}
+	}
+	return s.lock
+}
+
+//формирует секцию блокировки строк запроса SELECT
+func (s *Selector) lockSql() (string, error) {
+	if s.lock == nil {
+		return "", nil
+	}
+	if s.lock.strength == "" {
+		return "", fmt.Errorf("%w: SkipLocked, NoWait и Of указываются после ForUpdate или ForShare", ErrLockClause)
+	}
+	//PostgreSQL не блокирует строки результата UNION, count(*), DISTINCT, GROUP BY, HAVING и оконных функций
+	if len(s.setOperations) > 0 || s.count || s.distinct || len(s.distinctOn) > 0 {
+		return "", fmt.Errorf("%w: %s несовместим с UNION, Count и DISTINCT", ErrLockClause, s.lock.strength)
+	}
+	if len(s.groupBy) > 0 || !s.having.empty() {
+		return "", fmt.Errorf("%w: %s несовместим с GROUP BY и HAVING", ErrLockClause, s.lock.strength)
+	}
+	if s.hasWindowColumns() {
+		return "", fmt.Errorf("%w: %s несовместим с оконными функциями", ErrLockClause, s.lock.strength)
+	}
+	if s.dialect == DIALECT_SQLITE {
+		return "", fmt.Errorf("%w: %s в %v", ErrUnsupported, s.lock.strength, s.dialect)
+	}
+
+	parts := []string{s.lock.strength}
+	if len(s.lock.of) > 0 {
+		tables, err := s.identifierList(s.lock.of)
+		if err != nil {
+			return "", err
+		}
+		parts = append(parts, "OF "+tables)
+	}
+	if s.lock.wait != "" {
+		parts = append(parts, s.lock.wait)
+	}
+	return " " + strings.Join(parts, " "), nil
+}
+
+//возвращает true, если среди выбираемых полей есть оконные функции
+func (s *Selector) hasWindowColumns() bool {
+	for _, c := range s.columns {
+		if c.window != nil {
+			return true
+		}
+	}
+	return len(s.windows) > 0
+}
@@ -0,0 +1,69 @@
+package dbselector
+
+import "testing"
+
+func TestForUpdate(t *testing.T) {
+
+	sel := &Selector{}
+	sel.Select("job").Where("status", "=", "new").OrderBind("id", "asc").Limit(10).ForUpdate().SkipLocked()
+	sql, _, err := sel.Build()
+	compareError(t, nil, err)
+	compareSql(t, "SELECT * FROM \"job\" WHERE \"status\" = :status1 ORDER BY \"id\" asc LIMIT 10 FOR UPDATE SKIP LOCKED", sql)
+
+	sel = NewSelector(DIALECT_MYSQL)
+	sel.Select("job").As("j").Join("worker", "w").On("w.id", "=", Ident("j.worker_id")).ForShare().Of("j", "w").NoWait()
+	sql, _, err = sel.BuildRaw()
+	compareError(t, nil, err)
+	compareSql(t, "SELECT * FROM `job` AS `j` INNER JOIN `worker` AS `w` ON `w`.`id` = `j`.`worker_id` "+
+		"FOR SHARE OF `j`, `w` NOWAIT", sql)
+}
+
+func TestForUpdateErrors(t *testing.T) {
+
+	sel := NewSelector(DIALECT_SQLITE)
+	sel.Select("job").ForUpdate()
+	_, _, err := sel.Build()
+	compareError(t, ErrUnsupported, err)
+
+	sel = &Selector{}
+	sel.Select("job").SkipLocked()
+	_, _, err = sel.Build()
+	compareError(t, ErrLockClause, err)
+
+	sel = &Selector{}
+	sel.Select("job").ForUpdate().Of("job; drop")
+	_, _, err = sel.Build()
+	compareError(t, ErrInvalidIdentifier, err)
+
+	admins := &Selector{}
+	admins.Select("admin").Columns("id")
+	sel = &Selector{}
+	sel.Select("job").Columns("id").Union(admins).ForUpdate()
+	_, _, err = sel.Build()
+	compareError(t, ErrLockClause, err)
+
+	sel = &Selector{}
+	sel.Select("job").Count().ForUpdate()
+	_, _, err = sel.Build()
+	compareError(t, ErrLockClause, err)
+
+	sel = &Selector{}
+	sel.Select("job").Columns("status").Distinct().ForShare()
+	_, _, err = sel.Build()
+	compareError(t, ErrLockClause, err)
+
+	sel = &Selector{}
+	sel.Select("c").Columns("x").GroupBy("x").ForUpdate()
+	_, _, err = sel.Build()
+	compareError(t, ErrLockClause, err)
+
+	sel = &Selector{}
+	sel.Select("c").Having("count(*)", ">", 1).ForUpdate()
+	_, _, err = sel.Build()
+	compareError(t, ErrLockClause, err)
+
+	sel = &Selector{}
+	sel.Select("score").ColumnOver("rank()", NewWindow().OrderBy("points", "desc"), "place").ForUpdate()
+	_, _, err = sel.Build()
+	compareError(t, ErrLockClause, err)
+}
@@ -0,0 +1,96 @@
+package dbselector
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// операторы сравнения, допустимые во всех диалектах
+var commonOperators = []string{
+	"=", "<>", "!=", "<", "<=", ">", ">=",
+	"LIKE", "NOT LIKE",
+}
+
+// операторы сравнения, допустимые только в конкретном диалекте
+var dialectOperators = map[SqlDialect][]string{
+	DIALECT_POSTGRESS: {
+		"ILIKE", "NOT ILIKE", "SIMILAR TO", "NOT SIMILAR TO",
+		"IS DISTINCT FROM", "IS NOT DISTINCT FROM",
+		"~", "~*", "!~", "!~*", "@>", "<@", "&&", "@@",
+	},
+	DIALECT_MYSQL: {
+		"<=>", "REGEXP", "NOT REGEXP", "RLIKE", "NOT RLIKE", "SOUNDS LIKE",
+	},
+	DIALECT_SQLITE: {
+		"GLOB", "NOT GLOB", "REGEXP", "NOT REGEXP", "MATCH", "IS", "IS NOT",
+		"IS DISTINCT FROM", "IS NOT DISTINCT FROM",
+	},
+}
+
+var (
+	operatorsMutex sync.RWMutex
+	operators      = map[SqlDialect]map[string]bool{} // допустимые операторы по диалектам
+)
+
+func init() {
+	for _, dialect := range []SqlDialect{DIALECT_POSTGRESS, DIALECT_MYSQL, DIALECT_SQLITE} {
+		operators[dialect] = map[string]bool{}
+		for _, op := range commonOperators {
+			operators[dialect][op] = true
+		}
+		for _, op := range dialectOperators[dialect] {
+			operators[dialect][op] = true
+		}
+	}
+}
+
+/*Регистрирует дополнительный оператор сравнения для диалекта, после чего его
+можно использовать в Where, And, Or, On, Having. Оператор не может содержать
+кавычки, точку с запятой и комментарии.
+Параметры:
+	dialect - диалект, для которого регистрируется оператор
+	operator - оператор
+Результат:
+	ошибка или nil
+Пример использования:
+	err := RegisterOperator(DIALECT_POSTGRESS, "?|")
+*/
+func RegisterOperator(dialect SqlDialect, operator string) error {
+	op := normalizeOperator(operator)
+	if op == "" || strings.ContainsAny(op, "'\"`;\\") ||
+		strings.Contains(op, "--") || strings.Contains(op, "/*") {
+		return fmt.Errorf("%w: %q", ErrInvalidOperator, operator)
+	}
+
+	operatorsMutex.Lock()
+	defer operatorsMutex.Unlock()
+	if operators[dialect] == nil {
+		operators[dialect] = map[string]bool{}
+	}
+	operators[dialect][op] = true
+	return nil
+}
+
+//приводит оператор к каноническому виду: верхний регистр, одиночные пробелы
+func normalizeOperator(operator string) string {
+	return strings.ToUpper(strings.Join(strings.Fields(operator), " "))
+}
+
+//проверяет, что оператор допустим в диалекте селектора, и возвращает его в каноническом виде
+func (s *Selector) operator(operator string) (string, error) {
+	op := normalizeOperator(operator)
+
+	operatorsMutex.RLock()
+	ok := operators[s.dialect][op]
+	operatorsMutex.RUnlock()
+
+	if !ok && (op == "IS" || op == "IS NOT") {
+		//в PostgreSQL и MySQL за IS может следовать только NULL, TRUE, FALSE или DISTINCT FROM
+		return "", fmt.Errorf("%w: %q в %v, для NULL используйте IsNull или IsNotNull", ErrInvalidOperator, operator, s.dialect)
+	}
+	if !ok {
+		return "", fmt.Errorf("%w: %q в %v", ErrInvalidOperator, operator, s.dialect)
+	}
+	return op, nil
+}
@@ -0,0 +1,61 @@
+package dbselector
+
+import "testing"
+
+func TestOperatorValidation(t *testing.T) {
+
+	sel := &Selector{}
+	sel.Select("user").Where("name", "ilike", "vov%").And("tags", "@>", "{go}").
+		Or("id", "is  not  distinct from", 7)
+	sql, binds, err := sel.Build()
+	compareError(t, nil, err)
+
+	gageSql := "SELECT * FROM \"user\" WHERE \"name\" ILIKE :name1 AND \"tags\" @> :tags2 OR \"id\" IS NOT DISTINCT FROM :id3"
+	compareSql(t, gageSql, sql)
+	compareBinds(t, binds, map[string]interface{}{"name1": "vov%", "tags2": "{go}", "id3": 7})
+
+	sel = &Selector{}
+	sel.Select("user").Where("id", "= 1; DROP TABLE user; --", 1)
+	_, _, err = sel.Build()
+	compareError(t, ErrInvalidOperator, err)
+
+	sel = NewSelector(DIALECT_MYSQL)
+	sel.Select("user").Where("name", "ILIKE", "vov%")
+	_, _, err = sel.Build()
+	compareError(t, ErrInvalidOperator, err)
+
+	sel = NewSelector(DIALECT_MYSQL)
+	sel.Select("user").Where("name", "<=>", nil)
+	_, _, err = sel.BuildRaw()
+	compareError(t, nil, err)
+
+	//IS с параметром допустим только в SQLite
+	sel = &Selector{}
+	sel.Select("user").Where("email", "IS NOT", nil)
+	_, _, err = sel.Build()
+	compareError(t, ErrInvalidOperator, err)
+
+	sel = NewSelector(DIALECT_SQLITE)
+	sel.Select("user").Where("email", "is", nil)
+	sql, _, err = sel.BuildRaw()
+	compareError(t, nil, err)
+	compareSql(t, "SELECT * FROM \"user\" WHERE \"email\" IS ?", sql)
+}
+
+func TestRegisterOperator(t *testing.T) {
+
+	sel := NewSelector(DIALECT_SQLITE)
+	sel.Select("doc").Where("body", "%%", "go")
+	_, _, err := sel.BuildRaw()
+	compareError(t, ErrInvalidOperator, err)
+
+	compareError(t, nil, RegisterOperator(DIALECT_SQLITE, "%%"))
+	sql, binds, err := sel.BuildRaw()
+	compareError(t, nil, err)
+	compareSql(t, "SELECT * FROM \"doc\" WHERE \"body\" %% ?", sql)
+	compareBinds(t, binds, []interface{}{"go"})
+
+	compareError(t, ErrInvalidOperator, RegisterOperator(DIALECT_SQLITE, "= 1 --"))
+	compareError(t, ErrInvalidOperator, RegisterOperator(DIALECT_SQLITE, "';"))
+	compareError(t, ErrInvalidOperator, RegisterOperator(DIALECT_SQLITE, " "))
+}
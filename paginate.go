@@ -0,0 +1,138 @@
+package dbselector
+
+import "fmt"
+
+/*Формирует по одному построителю два запроса для постраничного вывода: запрос
+строк страницы с LIMIT и OFFSET и запрос общего количества строк. Из запроса
+количества исключаются сортировка, LIMIT, OFFSET и блокировка строк, а запрос
+с GROUP BY, HAVING, DISTINCT или объединением оборачивается во внешний
+SELECT count(*) FROM (...). Сам построитель не изменяется.
+Параметры:
+	page - номер страницы, начиная с 1
+	perPage - количество строк на странице
+Результат:
+	1. запрос строк страницы
+	2. запрос количества строк
+Пример использования:
+	selector := &Selector{}
+	selector.Select("user").Where("active", "=", true).OrderBind("name", "asc")
+	query, count := selector.Paginate(3, 20)
+	тогда query содержит: SELECT * FROM "user" WHERE "active" = :active1 ORDER BY "name" asc LIMIT 20 OFFSET 40
+	а count: SELECT count(*) FROM "user" WHERE "active" = :active1
+*/
+func (s *Selector) Paginate(page int, perPage int) (*Selector, *Selector) {
+	query := s.pageQuery(page, perPage)
+	count := s.countQuery()
+	if perPage < 1 {
+		count.setError(query.err)
+	}
+	return query, count
+}
+
+/*Формирует запрос строк страницы, который дополнительно возвращает общее количество
+строк в колонке total через оконную функцию count(*) OVER (), что позволяет обойтись
+одним запросом. Не поддерживается для запросов с DISTINCT и объединением.
+Параметры:
+	page - номер страницы, начиная с 1
+	perPage - количество строк на странице
+Результат:
+	запрос строк страницы
+Пример использования:
+	selector := &Selector{}
+	query := selector.Select("user").Where("active", "=", true).OrderBind("name", "asc").PaginateOver(1, 20)
+	тогда sql содержит: SELECT *, count(*) OVER () AS "total" FROM "user" WHERE "active" = :active1 ORDER BY "name" asc LIMIT 20
+*/
+func (s *Selector) PaginateOver(page int, perPage int) *Selector {
+	query := s.pageQuery(page, perPage)
+	if query.distinct || len(query.distinctOn) > 0 || len(query.setOperations) > 0 {
+		query.setError(fmt.Errorf("%w: count(*) OVER () для запроса с DISTINCT или объединением", ErrUnsupported))
+		return query
+	}
+	if len(query.columns) == 0 {
+		query.Columns("*")
+	}
+	return query.ColumnOver("count(*)", NewWindow(), "total")
+}
+
+//возвращает копию запроса, ограниченную строками страницы
+func (s *Selector) pageQuery(page int, perPage int) *Selector {
+	query := s.clone()
+	if perPage < 1 {
+		query.setError(fmt.Errorf("%w: %d", ErrInvalidPage, perPage))
+		return query
+	}
+	if page < 1 {
+		page = 1
+	}
+	query.limit = perPage
+	query.offset = (page - 1) * perPage
+	return query
+}
+
+//возвращает запрос количества строк, которые вернул бы запрос без LIMIT и OFFSET
+func (s *Selector) countQuery() *Selector {
+	count := s.clone()
+	count.orderBy, count.orders = "", nil
+	count.limit, count.offset = 0, 0
+	count.lock, count.keyset = nil, nil
+
+	if len(count.groupBy) == 0 && count.having.empty() && !count.distinct &&
+		len(count.distinctOn) == 0 && len(count.setOperations) == 0 {
+		count.columns, count.windows = nil, nil
+		count.count = true
+		return count
+	}
+
+	// группы и уникальные строки считаются во внешнем запросе
+	outer := &Selector{
+		operation:       QUERY_SELECT,
+		fromQuery:       count,
+		alias:           "t",
+		count:           true,
+		ctes:            count.ctes,
+		dialect:         count.dialect,
+		parameterPrefix: count.parameterPrefix,
+	}
+	count.ctes = nil
+	return outer
+}
+
+//возвращает копию запроса, изменение которой не затрагивает исходный запрос
+func (s *Selector) clone() *Selector {
+	c := *s
+	c.joins = make([]join, len(s.joins))
+	for i, j := range s.joins {
+		j.on = j.on.clone()
+		c.joins[i] = j
+	}
+	c.groupBy = append([]string(nil), s.groupBy...)
+	c.having = s.having.clone()
+	c.orders = append([]order(nil), s.orders...)
+	if s.sortable != nil {
+		c.sortable = make(map[string]bool, len(s.sortable))
+		for field := range s.sortable {
+			c.sortable[field] = true
+		}
+	}
+	c.columns = append([]column(nil), s.columns...)
+	c.distinctOn = append([]string(nil), s.distinctOn...)
+	c.where = s.where.clone()
+	c.returning = append([]string(nil), s.returning...)
+	c.values = append([]interface{}(nil), s.values...)
+	c.sets = append([]setItem(nil), s.sets...)
+	if s.conflict != nil {
+		conflict := *s.conflict
+		conflict.columns = append([]string(nil), s.conflict.columns...)
+		conflict.sets = append([]setItem(nil), s.conflict.sets...)
+		c.conflict = &conflict
+	}
+	c.ctes = append([]cte(nil), s.ctes...)
+	c.setOperations = append([]setOperation(nil), s.setOperations...)
+	c.windows = append([]namedWindow(nil), s.windows...)
+	if s.lock != nil {
+		lock := *s.lock
+		lock.of = append([]string(nil), s.lock.of...)
+		c.lock = &lock
+	}
+	return &c
+}
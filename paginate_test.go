@@ -0,0 +1,74 @@
+package dbselector
+
+import "testing"
+
+func TestPaginate(t *testing.T) {
+
+	sel := &Selector{}
+	sel.Select("user").Where("active", "=", true).OrderBind("name", "asc").ForUpdate()
+	query, count := sel.Paginate(3, 20)
+
+	sql, binds, err := query.Build()
+	compareError(t, nil, err)
+	compareSql(t, "SELECT * FROM \"user\" WHERE \"active\" = :active1 ORDER BY \"name\" asc LIMIT 20 OFFSET 40 FOR UPDATE", sql)
+	compareBinds(t, binds, map[string]interface{}{"active1": true})
+
+	sql, binds, err = count.Build()
+	compareError(t, nil, err)
+	compareSql(t, "SELECT count(*) FROM \"user\" WHERE \"active\" = :active1", sql)
+	compareBinds(t, binds, map[string]interface{}{"active1": true})
+
+	// исходный построитель не изменяется
+	query.And("age", ">", 18)
+	sql, _, err = sel.Build()
+	compareError(t, nil, err)
+	compareSql(t, "SELECT * FROM \"user\" WHERE \"active\" = :active1 ORDER BY \"name\" asc FOR UPDATE", sql)
+}
+
+func TestPaginateWrapped(t *testing.T) {
+
+	sel := NewSelector(DIALECT_MYSQL)
+	sel.Select("order").Columns("user_id", "sum(amount)").Where("paid", "=", true).
+		GroupBy("user_id").Having("sum(amount)", ">", 100).OrderBind("user_id", "asc")
+	query, count := sel.Paginate(0, 10)
+
+	sql, _, err := query.BuildRaw()
+	compareError(t, nil, err)
+	compareSql(t, "SELECT `user_id`, sum(`amount`) FROM `order` WHERE `paid` = ? GROUP BY `user_id` "+
+		"HAVING sum(`amount`) > ? ORDER BY `user_id` asc LIMIT 10", sql)
+
+	sql, binds, err := count.BuildRaw()
+	compareError(t, nil, err)
+	compareSql(t, "SELECT count(*) FROM (SELECT `user_id`, sum(`amount`) FROM `order` WHERE `paid` = ? "+
+		"GROUP BY `user_id` HAVING sum(`amount`) > ?) AS `t`", sql)
+	compareBinds(t, binds, []interface{}{true, 100})
+
+	active := (&Selector{}).Select("user").Where("active", "=", true)
+	sel = &Selector{}
+	sel.With("active_user", active).Select("active_user").Columns("email").Distinct().OrderBind("email", "asc")
+	_, count = sel.Paginate(2, 10)
+
+	sql, _, err = count.Build()
+	compareError(t, nil, err)
+	compareSql(t, "WITH \"active_user\" AS (SELECT * FROM \"user\" WHERE \"active\" = :active1) "+
+		"SELECT count(*) FROM (SELECT DISTINCT \"email\" FROM \"active_user\") AS \"t\"", sql)
+}
+
+func TestPaginateOver(t *testing.T) {
+
+	sel := &Selector{}
+	sel.Select("user").Where("active", "=", true).OrderBind("name", "asc")
+	sql, _, err := sel.PaginateOver(2, 20).BuildRaw()
+	compareError(t, nil, err)
+	compareSql(t, "SELECT *, count(*) OVER () AS \"total\" FROM \"user\" WHERE \"active\" = $1 "+
+		"ORDER BY \"name\" asc LIMIT 20 OFFSET 20", sql)
+
+	_, _, err = sel.Distinct().PaginateOver(1, 20).Build()
+	compareError(t, ErrUnsupported, err)
+
+	query, count := sel.Paginate(1, 0)
+	_, _, err = query.Build()
+	compareError(t, ErrInvalidPage, err)
+	_, _, err = count.Build()
+	compareError(t, ErrInvalidPage, err)
+}
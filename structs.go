@@ -0,0 +1,490 @@
+package dbselector
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+)
+
+/*Поле структуры, сохраняемое в БД. Имя поля и опции задаются тегом db:
+	db:"name,option1,option2"
+Если имя в теге не указано, используется имя поля структуры, поле с тегом db:"-"
+и неэкспортируемые поля пропускаются. Опции:
+	pk - поле является первичным ключом
+	auto - значение ключа генерирует БД, поле пропускается в INSERT без ExplicitKeys
+	omitempty - нулевое значение не записывается в БД
+	readonly - поле никогда не записывается в БД
+	insertonly - поле записывается только в INSERT
+	default - вместо нулевого значения записывается DEFAULT, т.е. применяется значение по умолчанию БД,
+		нулевое во всех строках INSERT поле пропускается
+	json - значение записывается в БД в виде JSON, nil значение записывается как NULL
+	prefix - поля вложенной структуры записываются как колонки с префиксом из тега
+*/
+type structField struct {
+	name       string // имя поля в БД
+	index      []int  // путь к полю во вложенных структурах
+	pk         bool   // поле является первичным ключом
+	auto       bool   // значение ключа генерирует БД
+	omitempty  bool   // нулевое значение не записывается
+	readonly   bool   // поле не записывается
+	insertOnly bool   // поле записывается только в INSERT
+	useDefault bool   // нулевое значение заменяется на DEFAULT
+	json       bool   // значение сериализуется в JSON
+	prefix     bool   // поля вложенной структуры записываются с префиксом
+	options    bool   // в теге указаны опции
+}
+
+// значение поля структуры, подготовленное для записи в БД
+type fieldValue struct {
+	value interface{}
+	zero  bool // значение поля нулевое
+}
+
+//формирует values секцию для запроса INSERT, возвращает также имена вставляемых колонок и биндинг
+func (s *Selector) valuesSql(raw bool) (string, []string, map[string]interface{}, error) {
+	binds := make(map[string]interface{})
+	resultSQL := ""
+	if len(s.values) == 0 {
+		return resultSQL, nil, binds, ErrNoValues
+	}
+
+	// сначала нужно получить имена полей
+	fields, err := getRowFields(s.values[0])
+	if err != nil {
+		return resultSQL, nil, binds, err
+	}
+
+	// затем значения полей всех строк
+	rows := make([][]fieldValue, 0, len(s.values))
+	for _, object := range s.values {
+		if rowType(object) != rowType(s.values[0]) {
+			return "", nil, binds, fmt.Errorf("%w: %T и %T", ErrMixedValues, s.values[0], object)
+		}
+		row, err := getRowValues(object, fields)
+		if err != nil {
+			return "", nil, binds, err
+		}
+		rows = append(rows, row)
+	}
+
+	// поля только для чтения, автоматически генерируемые ключи (если не указано ExplicitKeys)
+	// и omitempty или default поля с нулевым значением во всех строках пропускаются,
+	// т.е. получают значение по умолчанию без DEFAULT, который не поддерживается в SQLite
+	columns := make([]int, 0, len(fields))
+	names := make([]string, 0, len(fields))
+	quotedNames := make([]string, 0, len(fields))
+	for i, field := range fields {
+		if field.readonly || field.auto && !s.explicitKeys {
+			continue
+		}
+		if (field.omitempty || field.useDefault) && allZero(rows, i) {
+			continue
+		}
+		quoted, err := s.identifier(field.name)
+		if err != nil {
+			return "", nil, binds, err
+		}
+		columns = append(columns, i)
+		names = append(names, field.name)
+		quotedNames = append(quotedNames, quoted)
+	}
+	resultSQL += " (" + strings.Join(quotedNames, ", ") + ") VALUES "
+
+	values := make([]string, 0, len(rows))
+	for _, row := range rows {
+		placeholders := make([]string, 0, len(columns))
+		for _, i := range columns {
+			if (fields[i].omitempty || fields[i].useDefault) && row[i].zero {
+				if s.dialect == DIALECT_SQLITE {
+					return "", nil, binds, fmt.Errorf("%w: DEFAULT в VALUES в %v", ErrUnsupported, s.dialect)
+				}
+				placeholders = append(placeholders, "DEFAULT")
+				continue
+			}
+			bindName := s.getBindingName(fields[i].name, raw)
+			placeholders = append(placeholders, s.getPlaceholder(bindName, raw))
+			binds[bindName] = row[i].value
+		}
+		values = append(values, "("+strings.Join(placeholders, ", ")+")")
+	}
+	resultSQL += strings.Join(values, ", ")
+
+	return resultSQL, names, binds, nil
+}
+
+// значение для SET, вместо которого в запрос подставляется DEFAULT
+type defaultValue struct{}
+
+/*Добавляет к sql запросу UPDATE секцию SET со всеми полями структуры, описанными
+тегами db так же, как для INSERT. Поля первичного ключа, поля с опциями readonly
+и insertonly, а также нулевые поля с опцией omitempty пропускаются, нулевые поля
+с опцией default получают значение DEFAULT.
+Параметры:
+	obj - структура или указатель на структуру
+Результат:
+	ссылка Selector на самого себя
+Пример использования:
+	selector := &Selector{}
+	selector.Update("user").SetStruct(user).Where("id", "=", user.Id)
+*/
+func (s *Selector) SetStruct(obj interface{}) *Selector {
+	return s.setStructFields(nil, obj)
+}
+
+/*Добавляет к sql запросу UPDATE секцию SET только с теми полями структуры,
+значения которых в newObj отличаются от значений в oldObj. Правила отбора полей
+такие же, как в SetStruct. Если ни одно поле не изменилось, секция SET пуста
+и формирование запроса завершается ошибкой ErrNoSet.
+Параметры:
+	oldObj - исходное состояние записи
+	newObj - новое состояние записи того же типа
+Результат:
+	ссылка Selector на самого себя
+Пример использования:
+	selector := &Selector{}
+	selector.Update("user").SetChanged(old, user).Where("id", "=", user.Id)
+*/
+func (s *Selector) SetChanged(oldObj interface{}, newObj interface{}) *Selector {
+	if rowType(oldObj) != rowType(newObj) {
+		s.setError(fmt.Errorf("%w: %T и %T", ErrMixedValues, oldObj, newObj))
+		return s
+	}
+	return s.setStructFields(oldObj, newObj)
+}
+
+//добавляет в SET поля структуры newObj, если oldObj задан - только измененные
+func (s *Selector) setStructFields(oldObj interface{}, newObj interface{}) *Selector {
+	fields, err := getStructFields(newObj)
+	if err != nil {
+		s.setError(err)
+		return s
+	}
+	values, err := getRowValues(newObj, fields)
+	if err != nil {
+		s.setError(err)
+		return s
+	}
+	var oldValues []fieldValue
+	if oldObj != nil {
+		if oldValues, err = getRowValues(oldObj, fields); err != nil {
+			s.setError(err)
+			return s
+		}
+	}
+
+	for i, field := range fields {
+		if field.pk || field.readonly || field.insertOnly {
+			continue
+		}
+		// очищенное omitempty поле записывается, если прежнее значение было ненулевым
+		if field.omitempty && values[i].zero && (oldValues == nil || oldValues[i].zero) {
+			continue
+		}
+		if oldValues != nil && reflect.DeepEqual(oldValues[i].value, values[i].value) {
+			continue
+		}
+		if field.useDefault && values[i].zero {
+			s.Set(field.name, defaultValue{})
+			continue
+		}
+		s.Set(field.name, values[i].value)
+	}
+	return s
+}
+
+//возвращает тип строки данных без учета указателей
+func rowType(row interface{}) reflect.Type {
+	return indirectType(reflect.TypeOf(row))
+}
+
+//возвращает true, если значение поля с номером i нулевое во всех строках
+func allZero(rows [][]fieldValue, i int) bool {
+	for _, row := range rows {
+		if !row[i].zero {
+			return false
+		}
+	}
+	return true
+}
+
+/*
+Получает значения полей строки данных
+Параметры:
+row - структура, указатель на структуру или словарь с ключами-строками
+fields - срез описаний полей строки
+Возвращает:
+[]fieldValue - срез значений полей или пустой срез
+error - ошибка или nil
+*/
+func getRowValues(row interface{}, fields []structField) ([]fieldValue, error) {
+	res := make([]fieldValue, 0, len(fields))
+	v := indirectValue(reflect.ValueOf(row))
+	switch {
+	case v.Kind() == reflect.Map && v.Type().Key().Kind() == reflect.String:
+		if v.Len() != len(fields) {
+			return make([]fieldValue, 0), fmt.Errorf("%w: набор ключей словаря отличается", ErrMixedValues)
+		}
+	case v.Kind() != reflect.Struct:
+		return make([]fieldValue, 0), fmt.Errorf("%w: %T", ErrNotStruct, row)
+	}
+
+	for _, f := range fields {
+		var field reflect.Value
+		if v.Kind() == reflect.Map {
+			field = v.MapIndex(reflect.ValueOf(f.name).Convert(v.Type().Key()))
+			if !field.IsValid() {
+				return make([]fieldValue, 0), fmt.Errorf("%w: нет ключа %s", ErrMixedValues, f.name)
+			}
+		} else {
+			field = fieldByIndex(v, f.index)
+		}
+
+		fv := fieldValue{}
+		if field.IsValid() {
+			fv.value, fv.zero = field.Interface(), field.IsZero()
+		} else { // поле вложенной структуры, указатель на которую равен nil
+			fv.zero = true
+		}
+		if f.json && isNilValue(field) { // nil записывается как NULL, а не как JSON null
+			fv.value = nil
+		} else if f.json {
+			data, err := json.Marshal(fv.value)
+			if err != nil {
+				return make([]fieldValue, 0), fmt.Errorf("dbselector: поле %s: %w", f.name, err)
+			}
+			fv.value = string(data)
+		}
+		res = append(res, fv)
+	}
+	return res, nil
+}
+
+/* получает описания полей БД строки данных: для структуры по тегам db:,
+для словаря по его ключам в алфавитном порядке.
+row - структура, указатель на структуру или словарь с ключами-строками
+Возвращает:
+[]structField - срез описаний полей для БД или пустой срез
+error - ошибка или nil
+*/
+func getRowFields(row interface{}) ([]structField, error) {
+	v := indirectValue(reflect.ValueOf(row))
+	if v.Kind() == reflect.Map && v.Type().Key().Kind() == reflect.String {
+		keys := make([]string, 0, v.Len())
+		for _, key := range v.MapKeys() {
+			keys = append(keys, key.String())
+		}
+		sort.Strings(keys)
+
+		fields := make([]structField, 0, len(keys))
+		for _, key := range keys {
+			fields = append(fields, structField{name: key})
+		}
+		return fields, nil
+	}
+	return getStructFields(row)
+}
+
+/*Метаданные структуры для работы с БД: описания полей, номера ключевых полей
+и номера полей по именам колонок. Метаданные вычисляются один раз для каждого
+типа и разделяются всеми запросами, поэтому после создания не изменяются.
+*/
+type structMeta struct {
+	fields  []structField  // описания полей в порядке следования в структуре
+	keys    []int          // номера полей первичного ключа в fields
+	columns map[string]int // номер поля в fields по имени колонки
+	err     error          // ошибка разбора тегов структуры
+}
+
+// кэш метаданных структур, ключ reflect.Type, значение *structMeta
+var structCache sync.Map
+
+/* получает описания полей БД по тегу db: структуры или по имени,
+использование имени происходит если тег db: не указан или имя в нем пустое.
+Поля встроенных структур добавляются к полям самой структуры, а поля вложенной
+структуры с опцией prefix - с именем из тега в качестве префикса:
+	Home Address `db:"home_,prefix"` // колонки home_city, home_street
+Если ни одно поле не отмечено опцией pk, то поле с именем id (в любом регистре)
+без опций считается автоматически генерируемым ключом.
+Возвращаемый срез общий для всех вызовов и не должен изменяться.
+structure - структура данных или указатель на нее
+Возвращает:
+[]structField - срез описаний полей для БД или пустой срез
+error - ошибка или nil
+*/
+func getStructFields(structure interface{}) ([]structField, error) {
+	meta, err := getStructMeta(reflect.TypeOf(structure))
+	if err != nil {
+		return make([]structField, 0), fmt.Errorf("%w: %T", err, structure)
+	}
+	return meta.fields, nil
+}
+
+//возвращает метаданные структуры из кэша, вычисляя их при первом обращении
+func getStructMeta(sType reflect.Type) (*structMeta, error) {
+	sType = indirectType(sType)
+	if sType == nil || sType.Kind() != reflect.Struct {
+		return nil, ErrNotStruct
+	}
+
+	if cached, ok := structCache.Load(sType); ok {
+		meta := cached.(*structMeta)
+		return meta, meta.err
+	}
+	cached, _ := structCache.LoadOrStore(sType, newStructMeta(sType))
+	meta := cached.(*structMeta)
+	return meta, meta.err
+}
+
+//вычисляет метаданные структуры sType
+func newStructMeta(sType reflect.Type) *structMeta {
+	fields, err := collectStructFields(sType, "", nil, map[reflect.Type]bool{})
+	if err != nil {
+		return &structMeta{err: err}
+	}
+
+	meta := &structMeta{fields: fields, columns: make(map[string]int, len(fields))}
+	for i := range fields {
+		if fields[i].pk {
+			meta.keys = append(meta.keys, i)
+		}
+	}
+	// без явно заданного ключа используется прежнее правило: id - автоматический ключ
+	if len(meta.keys) == 0 {
+		for i := range fields {
+			if !fields[i].options && strings.ToLower(fields[i].name) == "id" {
+				fields[i].pk = true
+				fields[i].auto = true
+				meta.keys = append(meta.keys, i)
+			}
+		}
+	}
+	for i := range fields {
+		meta.columns[fields[i].name] = i
+	}
+	return meta
+}
+
+//рекурсивно собирает поля структуры sType, добавляя к именам prefix, а к номерам полей index
+func collectStructFields(sType reflect.Type, prefix string, index []int, visited map[reflect.Type]bool) ([]structField, error) {
+	if visited[sType] {
+		return nil, fmt.Errorf("%w: рекурсивная вложенность %v", ErrInvalidTag, sType)
+	}
+	visited[sType] = true
+	defer delete(visited, sType)
+
+	fields := make([]structField, 0, sType.NumField())
+	for i := 0; i < sType.NumField(); i++ { // i это номер поля структуры
+		field := sType.Field(i)
+		// неэкспортируемые поля пропускаются, кроме встроенных структур: их экспортируемые
+		// поля добавляются к полям структуры так же, как в encoding/json
+		unexported := field.PkgPath != ""
+		if unexported && !(field.Anonymous && field.Type.Kind() == reflect.Struct) {
+			continue
+		}
+
+		tag, _ := field.Tag.Lookup("db")
+		if tag == "-" {
+			continue
+		}
+
+		sf, err := parseTag(tag)
+		if err != nil {
+			return nil, fmt.Errorf("%w: поле %s", err, field.Name)
+		}
+		if unexported && sf.name != "" && !sf.prefix { // значение самой структуры недоступно
+			continue
+		}
+		fieldIndex := append(append(make([]int, 0, len(index)+1), index...), i)
+
+		nested := field.Type
+		if nested.Kind() == reflect.Ptr {
+			nested = nested.Elem()
+		}
+		if sf.prefix || field.Anonymous && sf.name == "" && nested.Kind() == reflect.Struct {
+			if nested.Kind() != reflect.Struct {
+				return nil, fmt.Errorf("%w: prefix у поля %s, которое не является структурой", ErrInvalidTag, field.Name)
+			}
+			embedded, err := collectStructFields(nested, prefix+sf.name, fieldIndex, visited)
+			if err != nil {
+				return nil, err
+			}
+			fields = append(fields, embedded...)
+			continue
+		}
+
+		if sf.name == "" {
+			sf.name = field.Name
+		}
+		sf.name = prefix + sf.name
+		sf.index = fieldIndex
+		fields = append(fields, sf)
+	}
+	return fields, nil
+}
+
+//возвращает true для отсутствующего значения и nil указателя, словаря, среза или интерфейса
+func isNilValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Invalid:
+		return true
+	case reflect.Ptr, reflect.Map, reflect.Slice, reflect.Interface:
+		return v.IsNil()
+	}
+	return false
+}
+
+//разыменовывает указатели, для nil указателя возвращает нулевой reflect.Value
+func indirectValue(v reflect.Value) reflect.Value {
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return reflect.Value{}
+		}
+		v = v.Elem()
+	}
+	return v
+}
+
+//возвращает поле по пути index, проходя через указатели на вложенные структуры
+func fieldByIndex(v reflect.Value, index []int) reflect.Value {
+	for _, i := range index {
+		v = indirectValue(v)
+		if !v.IsValid() {
+			return v
+		}
+		v = v.Field(i)
+	}
+	return v
+}
+
+//разбирает значение тега db вида "name,option1,option2"
+func parseTag(tag string) (structField, error) {
+	options := strings.Split(tag, ",")
+	sf := structField{name: strings.TrimSpace(options[0]), options: len(options) > 1}
+	for _, option := range options[1:] {
+		switch strings.TrimSpace(option) {
+		case "pk":
+			sf.pk = true
+		case "auto":
+			sf.auto = true
+		case "omitempty":
+			sf.omitempty = true
+		case "readonly":
+			sf.readonly = true
+		case "insertonly":
+			sf.insertOnly = true
+		case "default":
+			sf.useDefault = true
+		case "json":
+			sf.json = true
+		case "prefix":
+			sf.prefix = true
+		case "":
+		default:
+			return sf, fmt.Errorf("%w: неизвестная опция %q", ErrInvalidTag, option)
+		}
+	}
+	return sf, nil
+}
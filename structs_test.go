@@ -0,0 +1,265 @@
+package dbselector
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+type tagStruct struct {
+	Id       int64             `db:"id,pk,auto"`
+	Name     string            `mydb:"nick" db:"name"`
+	Email    string            `db:"email,omitempty"`
+	Created  time.Time         `db:"created,readonly"`
+	Role     string            `db:"role,default"`
+	Settings map[string]string `json:"settings" db:"settings,json"`
+	Author   string            `db:",insertonly"`
+	secret   string
+}
+
+func TestStructTags(t *testing.T) {
+
+	item := tagStruct{Id: 1, Name: "Vova", Settings: map[string]string{"lang": "ru"}, Author: "admin", secret: "x"}
+
+	sel := &Selector{}
+	sel.Insert("user").Values([]interface{}{item})
+	sql, binds, err := sel.Build()
+	compareError(t, nil, err)
+
+	gageSql := "INSERT INTO \"user\" (\"name\", \"settings\", \"Author\") " +
+		"VALUES (:name1, :settings2, :Author3)"
+	compareSql(t, gageSql, sql)
+
+	gage := map[string]interface{}{"name1": "Vova", "settings2": `{"lang":"ru"}`, "Author3": "admin"}
+	compareBinds(t, binds, gage)
+
+	// нулевое во всех строках default поле пропускается, поэтому работает и в SQLite
+	sel = NewSelector(DIALECT_SQLITE)
+	sel.Insert("user").Values(item)
+	sql, _, err = sel.BuildRaw()
+	compareError(t, nil, err)
+	compareSql(t, "INSERT INTO \"user\" (\"name\", \"settings\", \"Author\") VALUES (?, ?, ?)", sql)
+}
+
+func TestStructTagsOmitempty(t *testing.T) {
+
+	items := []interface{}{
+		tagStruct{Name: "Vova", Email: "vova@fulleren.io", Role: "admin"},
+		tagStruct{Name: "Dima"},
+	}
+
+	sel := &Selector{}
+	sel.Insert("user").Values(items)
+	sql, binds, err := sel.BuildRaw()
+	compareError(t, nil, err)
+
+	gageSql := "INSERT INTO \"user\" (\"name\", \"email\", \"role\", \"settings\", \"Author\") " +
+		"VALUES ($1, $2, $3, $4, $5), ($6, DEFAULT, DEFAULT, $7, $8)"
+	compareSql(t, gageSql, sql)
+	// nil словарь записывается как NULL, а не как JSON null
+	compareBinds(t, binds, []interface{}{"Vova", "vova@fulleren.io", "admin", nil, "", "Dima", nil, ""})
+
+	sel = NewSelector(DIALECT_SQLITE)
+	sel.Insert("user").Values(items)
+	_, _, err = sel.BuildRaw()
+	compareError(t, ErrUnsupported, err)
+}
+
+func TestStructTagsInvalid(t *testing.T) {
+
+	item := struct {
+		Name string `db:"name,omitemtpy"`
+	}{}
+
+	sel := &Selector{}
+	sel.Insert("user").Values([]interface{}{item})
+	_, _, err := sel.Build()
+	compareError(t, ErrInvalidTag, err)
+}
+
+type Timestamps struct {
+	Created time.Time `db:"created_at"`
+	Updated time.Time `db:"updated_at"`
+}
+
+type address struct {
+	City   string `db:"city"`
+	Street string `db:"street"`
+}
+
+type personStruct struct {
+	Id   int64  `db:"id,pk,auto"`
+	Name string `db:"name"`
+	Timestamps
+	Home *address `db:"home_,prefix"`
+	Work address  `db:"work_,prefix"`
+}
+
+func TestValuesNestedStructs(t *testing.T) {
+
+	now := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	items := []*personStruct{
+		{Name: "Vova", Timestamps: Timestamps{now, now}, Home: &address{"Moscow", "Arbat"}},
+		{Name: "Dima", Work: address{City: "Tver"}},
+	}
+
+	sel := &Selector{}
+	sel.Insert("person").Values(items)
+	sql, binds, err := sel.BuildRaw()
+	compareError(t, nil, err)
+
+	gageSql := "INSERT INTO \"person\" (\"name\", \"created_at\", \"updated_at\", \"home_city\", \"home_street\", " +
+		"\"work_city\", \"work_street\") VALUES ($1, $2, $3, $4, $5, $6, $7), ($8, $9, $10, $11, $12, $13, $14)"
+	compareSql(t, gageSql, sql)
+
+	gage := []interface{}{"Vova", now, now, "Moscow", "Arbat", "", "",
+		"Dima", time.Time{}, time.Time{}, nil, nil, "Tver", ""}
+	compareBinds(t, binds, gage)
+}
+
+func TestValuesPointerAndMap(t *testing.T) {
+
+	sel := &Selector{}
+	sel.Insert("user").Values(&testStruct{Id: 1, NumB: 2})
+	sql, _, err := sel.BuildRaw()
+	compareError(t, nil, err)
+	compareSql(t, "INSERT INTO \"user\" (\"Num_A\", \"num_b\", \"time\", \"num_c\") VALUES ($1, $2, $3, $4)", sql)
+
+	sel = &Selector{}
+	sel.Insert("user").Values([]map[string]interface{}{
+		{"name": "Vova", "age": 30},
+		{"name": "Dima", "age": 25},
+	})
+	sql, binds, err := sel.BuildRaw()
+	compareError(t, nil, err)
+	compareSql(t, "INSERT INTO \"user\" (\"age\", \"name\") VALUES ($1, $2), ($3, $4)", sql)
+	compareBinds(t, binds, []interface{}{30, "Vova", 25, "Dima"})
+
+	sel = &Selector{}
+	sel.Insert("user").Values([]map[string]interface{}{{"name": "Vova"}, {"email": "dima@fulleren.io"}})
+	_, _, err = sel.BuildRaw()
+	compareError(t, ErrMixedValues, err)
+}
+
+func TestStructMetaCache(t *testing.T) {
+
+	meta, err := getStructMeta(reflect.TypeOf(&personStruct{}))
+	compareError(t, nil, err)
+	again, err := getStructMeta(reflect.TypeOf(personStruct{}))
+	compareError(t, nil, err)
+	if meta != again {
+		t.Error("metadata is not cached")
+	}
+	if len(meta.keys) != 1 || meta.fields[meta.keys[0]].name != "id" {
+		t.Errorf("wrong key fields: %v", meta.keys)
+	}
+	if i, ok := meta.columns["home_street"]; !ok || meta.fields[i].name != "home_street" {
+		t.Errorf("no column home_street: %v", meta.columns)
+	}
+
+	legacy, err := getStructMeta(reflect.TypeOf(testStruct{}))
+	compareError(t, nil, err)
+	if len(legacy.keys) != 1 || !legacy.fields[legacy.keys[0]].auto {
+		t.Errorf("wrong legacy key fields: %v", legacy.keys)
+	}
+
+	_, err = getStructMeta(reflect.TypeOf(1))
+	compareError(t, ErrNotStruct, err)
+}
+
+func TestStructMetaConcurrent(t *testing.T) {
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sel := &Selector{}
+			sel.Insert("user").Values(tagStruct{Name: "Vova"})
+			if _, _, err := sel.Build(); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestSetStruct(t *testing.T) {
+
+	item := &tagStruct{Id: 5, Name: "Vova", Settings: map[string]string{"lang": "ru"}, Author: "admin"}
+
+	sel := &Selector{}
+	sel.Update("user").SetStruct(item).Where("id", "=", item.Id)
+	sql, binds, err := sel.BuildRaw()
+	compareError(t, nil, err)
+
+	gageSql := "UPDATE \"user\" SET \"name\" = $1, \"role\" = DEFAULT, \"settings\" = $2 WHERE \"id\" = $3"
+	compareSql(t, gageSql, sql)
+	compareBinds(t, binds, []interface{}{"Vova", `{"lang":"ru"}`, int64(5)})
+
+	sel = NewSelector(DIALECT_SQLITE)
+	sel.Update("user").SetStruct(item)
+	_, _, err = sel.BuildRaw()
+	compareError(t, ErrUnsupported, err)
+}
+
+func TestSetChanged(t *testing.T) {
+
+	old := tagStruct{Id: 5, Name: "Vova", Email: "vova@fulleren.io", Role: "user"}
+	item := old
+	item.Email = "vladimir@fulleren.io"
+	item.Role = "admin"
+
+	sel := &Selector{}
+	sel.Update("user").SetChanged(old, &item).Where("id", "=", item.Id)
+	sql, binds, err := sel.Build()
+	compareError(t, nil, err)
+
+	gageSql := "UPDATE \"user\" SET \"email\" = :email1, \"role\" = :role2 WHERE \"id\" = :id3"
+	compareSql(t, gageSql, sql)
+
+	gage := map[string]interface{}{"email1": "vladimir@fulleren.io", "role2": "admin", "id3": int64(5)}
+	compareBinds(t, binds, gage)
+
+	cleared := old
+	cleared.Email = ""
+
+	sel = &Selector{}
+	sel.Update("user").SetChanged(old, cleared).Where("id", "=", item.Id)
+	sql, binds, err = sel.Build()
+	compareError(t, nil, err)
+	compareSql(t, "UPDATE \"user\" SET \"email\" = :email1 WHERE \"id\" = :id2", sql)
+	compareBinds(t, binds, map[string]interface{}{"email1": "", "id2": int64(5)})
+
+	sel = &Selector{}
+	sel.Update("user").SetChanged(old, old)
+	_, _, err = sel.Build()
+	compareError(t, ErrNoSet, err)
+
+	sel = &Selector{}
+	sel.Update("user").SetChanged(old, testStruct{})
+	_, _, err = sel.Build()
+	compareError(t, ErrMixedValues, err)
+}
+
+type timestamps struct {
+	Created time.Time `db:"created_at"`
+	secret  string
+}
+
+func TestValuesUnexportedEmbedded(t *testing.T) {
+
+	created := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	item := struct {
+		timestamps
+		Name string `db:"name"`
+	}{timestamps{created, "x"}, "Vova"}
+
+	sel := &Selector{}
+	sel.Insert("user").Values(item)
+	sql, binds, err := sel.BuildRaw()
+	compareError(t, nil, err)
+	compareSql(t, "INSERT INTO \"user\" (\"created_at\", \"name\") VALUES ($1, $2)", sql)
+	compareBinds(t, binds, []interface{}{created, "Vova"})
+}
@@ -0,0 +1,215 @@
+package dbselector
+
+import "fmt"
+
+/*Задает запрос SELECT, строки которого вставляются запросом INSERT вместо Values.
+Список колонок вставки задается через Columns, если он не задан - колонки
+в запросе не перечисляются. Параметры обоих запросов нумеруются последовательно,
+вложенный запрос формируется в диалекте внешнего.
+Параметры:
+	source - запрос SELECT
+Результат:
+	ссылка Selector на самого себя
+Пример использования:
+	old := &Selector{}
+	old.Select("user").Columns("id", "name").Where("created", "<", since)
+	selector := &Selector{}
+	selector.Insert("archive").Columns("id", "name").From(old)
+	тогда sql содержит: INSERT INTO "archive" ("id", "name") SELECT "id", "name" FROM "user" WHERE "created" < :created1
+*/
+func (s *Selector) From(source *Selector) *Selector {
+	s.source = source
+	return s
+}
+
+//формирует источник строк INSERT ... SELECT, возвращает также имена вставляемых колонок и биндинг
+func (s *Selector) insertSelectSql(raw bool) (string, []string, map[string]interface{}, error) {
+	if len(s.values) > 0 {
+		return "", nil, map[string]interface{}{}, fmt.Errorf("%w: для INSERT заданы и Values, и From", ErrMixedValues)
+	}
+
+	resultSQL := ""
+	columns := make([]string, 0, len(s.columns))
+	for _, c := range s.columns {
+		if c.expr != "" || c.alias != "" || c.window != nil {
+			return "", nil, map[string]interface{}{}, fmt.Errorf("%w: выражение в списке колонок INSERT", ErrInvalidIdentifier)
+		}
+		columns = append(columns, c.field)
+	}
+	if len(columns) > 0 {
+		names, err := s.identifierList(columns)
+		if err != nil {
+			return "", nil, map[string]interface{}{}, err
+		}
+		resultSQL += " (" + names + ")"
+	}
+
+	sourceSql, binds, err := s.subquerySql(s.source, raw)
+	if err != nil {
+		return "", nil, binds, err
+	}
+	return resultSQL + " " + sourceSql, columns, binds, nil
+}
+
+//формирует секцию FROM запроса SELECT: таблицу или вложенный запрос с псевдонимами
+func (s *Selector) fromSql(raw bool) (string, map[string]interface{}, error) {
+	if s.fromQuery == nil {
+		sql, err := s.tableSql(s.tableName, s.alias)
+		return sql, map[string]interface{}{}, err
+	}
+	if s.alias == "" {
+		return "", map[string]interface{}{}, fmt.Errorf("%w: для вложенного запроса в FROM нужен псевдоним", ErrInvalidIdentifier)
+	}
+
+	alias, err := s.identifier(s.alias)
+	if err != nil {
+		return "", map[string]interface{}{}, err
+	}
+	sql, binds, err := s.subquerySql(s.fromQuery, raw)
+	if err != nil {
+		return "", binds, err
+	}
+	return "(" + sql + ") AS " + alias, binds, nil
+}
+
+//формирует вложенный запрос в диалекте внешнего, продолжая нумерацию его параметров
+func (s *Selector) subquerySql(sub *Selector, raw bool) (string, map[string]interface{}, error) {
+	if sub == nil || sub.operation != QUERY_SELECT && sub.operation != "" {
+		return "", map[string]interface{}{}, ErrNotSelect
+	}
+	nested := *sub
+	nested.dialect = s.dialect
+	nested.parameterCounter = s.parameterCounter
+	sql, binds, err := nested.render(raw)
+	if err != nil {
+		return "", binds, err
+	}
+	s.parameterCounter = nested.parameterCounter
+	return sql, binds, nil
+}
+
+// условие field IN (SELECT ...) / field NOT IN (SELECT ...)
+type inSelectClause struct {
+	field string
+	sub   *Selector
+	not   bool
+}
+
+// условие EXISTS (SELECT ...) / NOT EXISTS (SELECT ...)
+type existsClause struct {
+	sub *Selector
+	not bool
+}
+
+/*Добавляет к sql запросу условие WHERE поле IN вложенный запрос. Параметры
+вложенного запроса нумеруются вслед за параметрами внешнего.
+Параметры:
+	field - имя поля в таблице БД
+	sub - вложенный запрос SELECT
+Результат:
+	ссылка Selector на самого себя
+Пример использования:
+	authors := &Selector{}
+	authors.Select("post").Columns("user_id").Where("published", "=", true)
+	selector := &Selector{}
+	selector.Select("user").WhereInSelector("id", authors)
+	тогда sql содержит: SELECT * FROM "user" WHERE "id" IN (SELECT "user_id" FROM "post" WHERE "published" = :published1)
+*/
+func (s *Selector) WhereInSelector(field string, sub *Selector) *Selector {
+	s.where.add(conjunctionAnd, InSelector(field, sub))
+	return s
+}
+
+// Добавляет к sql запросу условие AND поле IN вложенный запрос, см. WhereInSelector
+func (s *Selector) AndInSelector(field string, sub *Selector) *Selector {
+	s.where.add(conjunctionAnd, InSelector(field, sub))
+	return s
+}
+
+// Добавляет к sql запросу условие OR поле IN вложенный запрос, см. WhereInSelector
+func (s *Selector) OrInSelector(field string, sub *Selector) *Selector {
+	s.where.add(conjunctionOr, InSelector(field, sub))
+	return s
+}
+
+/*Добавляет к sql запросу условие WHERE EXISTS (вложенный запрос). Для связи
+с внешним запросом поле внешней таблицы передается во вложенный как Ident.
+Параметры:
+	sub - вложенный запрос SELECT
+Результат:
+	ссылка Selector на самого себя
+Пример использования:
+	posts := &Selector{}
+	posts.Select("post").As("p").Columns("id").Where("p.user_id", "=", Ident("u.id"))
+	selector := &Selector{}
+	selector.Select("user").As("u").WhereExists(posts)
+	тогда sql содержит: SELECT * FROM "user" AS "u" WHERE EXISTS (SELECT "id" FROM "post" AS "p" WHERE "p"."user_id" = "u"."id")
+*/
+func (s *Selector) WhereExists(sub *Selector) *Selector {
+	s.where.add(conjunctionAnd, Exists(sub))
+	return s
+}
+
+// Добавляет к sql запросу условие WHERE NOT EXISTS (вложенный запрос), см. WhereExists
+func (s *Selector) WhereNotExists(sub *Selector) *Selector {
+	s.where.add(conjunctionAnd, NotExists(sub))
+	return s
+}
+
+// Условие field IN (sub)
+func InSelector(field string, sub *Selector) Condition {
+	return inSelectClause{field: field, sub: sub}
+}
+
+// Условие field NOT IN (sub)
+func NotInSelector(field string, sub *Selector) Condition {
+	return inSelectClause{field: field, sub: sub, not: true}
+}
+
+// Условие EXISTS (sub)
+func Exists(sub *Selector) Condition {
+	return existsClause{sub: sub}
+}
+
+// Условие NOT EXISTS (sub)
+func NotExists(sub *Selector) Condition {
+	return existsClause{sub: sub, not: true}
+}
+
+//формирует вложенный запрос в скобках и добавляет его параметры в биндинг
+func (s *Selector) subqueryValue(sub *Selector, raw bool, binds map[string]interface{}) (string, error) {
+	sql, subBinds, err := s.subquerySql(sub, raw)
+	if err != nil {
+		return "", err
+	}
+	for k, v := range subBinds {
+		binds[k] = v
+	}
+	return "(" + sql + ")", nil
+}
+
+func (c inSelectClause) conditionSql(s *Selector, raw bool, binds map[string]interface{}) (string, error) {
+	field, err := s.conditionField(c.field)
+	if err != nil {
+		return "", err
+	}
+	sub, err := s.subqueryValue(c.sub, raw, binds)
+	if err != nil {
+		return "", err
+	}
+	if c.not {
+		return fmt.Sprintf("%v NOT IN %v", field, sub), nil
+	}
+	return fmt.Sprintf("%v IN %v", field, sub), nil
+}
+
+func (c existsClause) conditionSql(s *Selector, raw bool, binds map[string]interface{}) (string, error) {
+	sub, err := s.subqueryValue(c.sub, raw, binds)
+	if err != nil {
+		return "", err
+	}
+	if c.not {
+		return "NOT EXISTS " + sub, nil
+	}
+	return "EXISTS " + sub, nil
+}
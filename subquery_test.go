@@ -0,0 +1,151 @@
+package dbselector
+
+import "testing"
+
+func TestInsertSelect(t *testing.T) {
+
+	old := &Selector{}
+	old.Select("user").Columns("id", "name").Where("created", "<", "2021-01-01").And("active", "=", false)
+
+	sel := &Selector{}
+	sel.Insert("archive").Columns("id", "name").From(old).Returning("id")
+	sql, binds, err := sel.BuildRaw()
+	compareError(t, nil, err)
+
+	gageSql := "INSERT INTO \"archive\" (\"id\", \"name\") SELECT \"id\", \"name\" FROM \"user\" " +
+		"WHERE \"created\" < $1 AND \"active\" = $2 RETURNING \"id\""
+	compareSql(t, gageSql, sql)
+	compareBinds(t, binds, []interface{}{"2021-01-01", false})
+
+	sel = NewSelector(DIALECT_MYSQL)
+	sel.Insert("archive").From(old)
+	sql, _, err = sel.BuildRaw()
+	compareError(t, nil, err)
+	compareSql(t, "INSERT INTO `archive` SELECT `id`, `name` FROM `user` WHERE `created` < ? AND `active` = ?", sql)
+}
+
+func TestInsertSelectUpsert(t *testing.T) {
+
+	old := &Selector{}
+	old.Select("user").Columns("id", "name").Where("active", "=", false)
+
+	sel := &Selector{}
+	sel.SetParameterPrefix("p_")
+	sel.Insert("archive").Columns("id", "name").From(old).OnConflict("id").DoUpdate().DoUpdateSet("archived", true)
+	sql, binds, err := sel.Build()
+	compareError(t, nil, err)
+
+	gageSql := "INSERT INTO \"archive\" (\"id\", \"name\") SELECT \"id\", \"name\" FROM \"user\" WHERE \"active\" = :active1 " +
+		"ON CONFLICT (\"id\") DO UPDATE SET \"name\" = EXCLUDED.\"name\", \"archived\" = :p_archived2"
+	compareSql(t, gageSql, sql)
+	compareBinds(t, binds, map[string]interface{}{"active1": false, "p_archived2": true})
+}
+
+func TestInsertSelectErrors(t *testing.T) {
+
+	sel := &Selector{}
+	sel.Insert("archive").From((&Selector{}).Delete("user"))
+	_, _, err := sel.Build()
+	compareError(t, ErrNotSelect, err)
+
+	sel = &Selector{}
+	sel.Insert("archive").Values(testStruct{}).From((&Selector{}).Select("user"))
+	_, _, err = sel.Build()
+	compareError(t, ErrMixedValues, err)
+
+	sel = &Selector{}
+	sel.Insert("archive").From((&Selector{}).Select("user").CloseBracket())
+	_, _, err = sel.Build()
+	compareError(t, ErrUnbalancedBrackets, err)
+}
+
+func TestWhereInSelector(t *testing.T) {
+
+	authors := &Selector{}
+	authors.Select("post").Columns("user_id").Where("published", "=", true)
+
+	sel := &Selector{}
+	sel.Select("user").Where("active", "=", true).AndInSelector("id", authors).And("age", ">", 18)
+	sql, binds, err := sel.Build()
+	compareError(t, nil, err)
+
+	gageSql := "SELECT * FROM \"user\" WHERE \"active\" = :active1 AND \"id\" IN " +
+		"(SELECT \"user_id\" FROM \"post\" WHERE \"published\" = :published2) AND \"age\" > :age3"
+	compareSql(t, gageSql, sql)
+	compareBinds(t, binds, map[string]interface{}{"active1": true, "published2": true, "age3": 18})
+
+	sel = NewSelector(DIALECT_SQLITE)
+	sel.Select("user").WhereExpr(AnyOf(NotInSelector("id", authors), Eq("role", "admin")))
+	rawSql, rawBinds, err := sel.BuildRaw()
+	compareError(t, nil, err)
+
+	gageSql = "SELECT * FROM \"user\" WHERE (\"id\" NOT IN " +
+		"(SELECT \"user_id\" FROM \"post\" WHERE \"published\" = ?) OR \"role\" = ?)"
+	compareSql(t, gageSql, rawSql)
+	compareBinds(t, rawBinds, []interface{}{true, "admin"})
+}
+
+func TestWhereExists(t *testing.T) {
+
+	posts := &Selector{}
+	posts.Select("post").As("p").Columns("id").Where("p.user_id", "=", Ident("u.id")).And("p.rating", ">", 4)
+
+	sel := &Selector{}
+	sel.Select("user").As("u").Where("u.active", "=", true).WhereExists(posts)
+	sql, binds, err := sel.BuildRaw()
+	compareError(t, nil, err)
+
+	gageSql := "SELECT * FROM \"user\" AS \"u\" WHERE \"u\".\"active\" = $1 AND EXISTS " +
+		"(SELECT \"id\" FROM \"post\" AS \"p\" WHERE \"p\".\"user_id\" = \"u\".\"id\" AND \"p\".\"rating\" > $2)"
+	compareSql(t, gageSql, sql)
+	compareBinds(t, binds, []interface{}{true, 4})
+
+	sel = NewSelector(DIALECT_MYSQL)
+	sel.Select("user").As("u").WhereNotExists(posts)
+	sql, _, err = sel.BuildRaw()
+	compareError(t, nil, err)
+
+	gageSql = "SELECT * FROM `user` AS `u` WHERE NOT EXISTS " +
+		"(SELECT `id` FROM `post` AS `p` WHERE `p`.`user_id` = `u`.`id` AND `p`.`rating` > ?)"
+	compareSql(t, gageSql, sql)
+}
+
+func TestWhereScalarSubquery(t *testing.T) {
+
+	avg := &Selector{}
+	avg.Select("salary").ColumnExpr("avg(amount)", "").Where("year", "=", 2021)
+
+	sel := &Selector{}
+	sel.Select("salary").Where("year", "=", 2021).And("amount", ">", avg)
+	sql, binds, err := sel.BuildRaw()
+	compareError(t, nil, err)
+
+	gageSql := "SELECT * FROM \"salary\" WHERE \"year\" = $1 AND \"amount\" > " +
+		"(SELECT avg(amount) FROM \"salary\" WHERE \"year\" = $2)"
+	compareSql(t, gageSql, sql)
+	compareBinds(t, binds, []interface{}{2021, 2021})
+
+	sel = &Selector{}
+	sel.Select("user").WhereExpr(Eq("id", (&Selector{}).Update("user")))
+	_, _, err = sel.Build()
+	compareError(t, ErrNotSelect, err)
+}
+
+func TestSelectFromSubquery(t *testing.T) {
+
+	sub := (&Selector{}).Select("user").Columns("id", "name").Where("active", "=", true)
+
+	//вложенный запрос в FROM задается только внутри пакета
+	sel := &Selector{fromQuery: sub}
+	sel.Select("").As("u").Columns("u.name").Where("u.id", ">", 10)
+	sql, binds, err := sel.BuildRaw()
+	compareError(t, nil, err)
+	compareSql(t, "SELECT \"u\".\"name\" FROM (SELECT \"id\", \"name\" FROM \"user\" WHERE \"active\" = $1) AS \"u\" "+
+		"WHERE \"u\".\"id\" > $2", sql)
+	compareBinds(t, binds, []interface{}{true, 10})
+
+	sel = &Selector{fromQuery: sub}
+	sel.Select("")
+	_, _, err = sel.Build()
+	compareError(t, ErrInvalidIdentifier, err)
+}
@@ -0,0 +1,87 @@
+package dbselector
+
+import "fmt"
+
+// операции над множествами строк запросов SELECT
+const (
+	setUnion     = "UNION"
+	setUnionAll  = "UNION ALL"
+	setIntersect = "INTERSECT"
+	setExcept    = "EXCEPT"
+)
+
+// запрос SELECT, объединяемый с основным запросом
+type setOperation struct {
+	kind  string    //UNION, UNION ALL, INTERSECT или EXCEPT
+	query *Selector //объединяемый запрос
+}
+
+/*Объединяет строки запроса со строками другого запроса SELECT без повторов: UNION.
+Сортировка, LIMIT и OFFSET основного запроса применяются ко всему результату,
+объединяемые запросы с собственной сортировкой, ограничением или секцией WITH заключаются в скобки
+(SQLite этого не поддерживает). Параметры всех запросов нумеруются последовательно.
+Параметры:
+	query - объединяемый запрос SELECT
+Результат:
+	ссылка Selector на самого себя
+Пример использования:
+	admins := &Selector{}
+	admins.Select("admin").Columns("email")
+	selector := &Selector{}
+	selector.Select("user").Columns("email").Where("active", "=", true).Union(admins).OrderBind("email", "asc").Limit(10)
+	тогда sql содержит: SELECT "email" FROM "user" WHERE "active" = :active1
+		UNION SELECT "email" FROM "admin" ORDER BY "email" asc LIMIT 10
+*/
+func (s *Selector) Union(query *Selector) *Selector {
+	return s.addSetOperation(setUnion, query)
+}
+
+// Объединяет строки запроса со строками другого запроса с повторами: UNION ALL, см. Union
+func (s *Selector) UnionAll(query *Selector) *Selector {
+	return s.addSetOperation(setUnionAll, query)
+}
+
+// Оставляет строки запроса, которые есть в результате другого запроса: INTERSECT, см. Union
+func (s *Selector) Intersect(query *Selector) *Selector {
+	return s.addSetOperation(setIntersect, query)
+}
+
+// Оставляет строки запроса, которых нет в результате другого запроса: EXCEPT, см. Union
+func (s *Selector) Except(query *Selector) *Selector {
+	return s.addSetOperation(setExcept, query)
+}
+
+func (s *Selector) addSetOperation(kind string, query *Selector) *Selector {
+	s.setOperations = append(s.setOperations, setOperation{kind: kind, query: query})
+	return s
+}
+
+//формирует объединяемые запросы и добавляет их параметры в биндинг
+func (s *Selector) setOperationsSql(raw bool, binds map[string]interface{}) (string, error) {
+	resultSql := ""
+	for _, op := range s.setOperations {
+		sql, queryBinds, err := s.subquerySql(op.query, raw)
+		if err != nil {
+			return "", err
+		}
+		for k, v := range queryBinds {
+			binds[k] = v
+		}
+
+		if op.query.compound() {
+			if s.dialect == DIALECT_SQLITE {
+				return "", fmt.Errorf("%w: сортировка, ограничение или WITH в объединяемом запросе в %v", ErrUnsupported, s.dialect)
+			}
+			sql = "(" + sql + ")"
+		}
+		resultSql += " " + op.kind + " " + sql
+	}
+	return resultSql, nil
+}
+
+//возвращает true, если запрос в составе объединения нужно заключить в скобки:
+//у него есть собственная сортировка, ограничение, объединение или секция WITH
+func (s *Selector) compound() bool {
+	return s.orderBy != "" || len(s.orders) > 0 || s.limit > 0 || s.offset > 0 ||
+		len(s.setOperations) > 0 || len(s.ctes) > 0
+}
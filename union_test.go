@@ -0,0 +1,83 @@
+package dbselector
+
+import "testing"
+
+func TestUnion(t *testing.T) {
+
+	admins := &Selector{}
+	admins.Select("admin").Columns("email").Where("blocked", "=", false)
+
+	sel := &Selector{}
+	sel.Select("user").Columns("email").Where("active", "=", true).Union(admins).OrderBind("email", "asc").Limit(10).Offset(20)
+	sql, binds, err := sel.Build()
+	compareError(t, nil, err)
+
+	gageSql := "SELECT \"email\" FROM \"user\" WHERE \"active\" = :active1 " +
+		"UNION SELECT \"email\" FROM \"admin\" WHERE \"blocked\" = :blocked2 ORDER BY \"email\" asc LIMIT 10 OFFSET 20"
+	compareSql(t, gageSql, sql)
+	compareBinds(t, binds, map[string]interface{}{"active1": true, "blocked2": false})
+
+	rawSql, rawBinds, err := sel.BuildRaw()
+	compareError(t, nil, err)
+	gageSql = "SELECT \"email\" FROM \"user\" WHERE \"active\" = $1 " +
+		"UNION SELECT \"email\" FROM \"admin\" WHERE \"blocked\" = $2 ORDER BY \"email\" asc LIMIT 10 OFFSET 20"
+	compareSql(t, gageSql, rawSql)
+	compareBinds(t, rawBinds, []interface{}{true, false})
+}
+
+func TestUnionCount(t *testing.T) {
+
+	admins := &Selector{}
+	admins.Select("admin").Columns("email").Where("blocked", "=", false)
+
+	sel := &Selector{}
+	sel.Select("user").Columns("email").Where("active", "=", true).Union(admins).Count()
+	sql, binds, err := sel.BuildRaw()
+	compareError(t, nil, err)
+	compareSql(t, "SELECT count(*) FROM (SELECT \"email\" FROM \"user\" WHERE \"active\" = $1 "+
+		"UNION SELECT \"email\" FROM \"admin\" WHERE \"blocked\" = $2) AS \"t\"", sql)
+	compareBinds(t, binds, []interface{}{true, false})
+}
+
+func TestSetOperations(t *testing.T) {
+
+	first := (&Selector{}).Select("a").Columns("id").Where("x", "=", 1)
+	second := (&Selector{}).Select("b").Columns("id").Where("x", "=", 2)
+	third := (&Selector{}).Select("c").Columns("id").Where("x", "=", 3).OrderBind("id", "desc").Limit(5)
+
+	sel := NewSelector(DIALECT_MYSQL)
+	sel.Select("d").Columns("id").UnionAll(first).Intersect(second).Except(third)
+	sql, binds, err := sel.BuildRaw()
+	compareError(t, nil, err)
+
+	gageSql := "SELECT `id` FROM `d` UNION ALL SELECT `id` FROM `a` WHERE `x` = ? " +
+		"INTERSECT SELECT `id` FROM `b` WHERE `x` = ? EXCEPT (SELECT `id` FROM `c` WHERE `x` = ? ORDER BY `id` desc LIMIT 5)"
+	compareSql(t, gageSql, sql)
+	compareBinds(t, binds, []interface{}{1, 2, 3})
+
+	sel = NewSelector(DIALECT_SQLITE)
+	sel.Select("d").Columns("id").Except(third)
+	_, _, err = sel.BuildRaw()
+	compareError(t, ErrUnsupported, err)
+
+	archived := (&Selector{}).Select("archive").Where("year", "=", 2020)
+	withCte := (&Selector{}).With("old", archived).Select("old").Columns("id")
+
+	sel = &Selector{}
+	sel.Select("d").Columns("id").Where("x", "=", 0).Union(withCte)
+	sql, binds, err = sel.BuildRaw()
+	compareError(t, nil, err)
+	compareSql(t, "SELECT \"id\" FROM \"d\" WHERE \"x\" = $1 UNION "+
+		"(WITH \"old\" AS (SELECT * FROM \"archive\" WHERE \"year\" = $2) SELECT \"id\" FROM \"old\")", sql)
+	compareBinds(t, binds, []interface{}{0, 2020})
+
+	sel = NewSelector(DIALECT_SQLITE)
+	sel.Select("d").Columns("id").Union(withCte)
+	_, _, err = sel.BuildRaw()
+	compareError(t, ErrUnsupported, err)
+
+	sel = &Selector{}
+	sel.Select("d").Union((&Selector{}).Insert("a"))
+	_, _, err = sel.BuildRaw()
+	compareError(t, ErrNotSelect, err)
+}
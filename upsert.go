@@ -0,0 +1,194 @@
+package dbselector
+
+import (
+	"fmt"
+	"strings"
+)
+
+// описание секции ON CONFLICT (ON DUPLICATE KEY UPDATE в MySQL) запроса INSERT
+type onConflict struct {
+	columns   []string  //колонки ограничения уникальности, по которому определяется конфликт
+	doNothing bool      //при конфликте строка пропускается
+	doUpdate  bool      //при конфликте строка обновляется
+	updateAll bool      //обновляются все вставляемые колонки, кроме колонок конфликта
+	sets      []setItem //обновляемые колонки
+}
+
+// значение для DO UPDATE SET, которое берется из вставляемой строки (EXCLUDED.col)
+type excludedValue struct{}
+
+/*Добавляет к запросу INSERT обработку конфликта уникальности. Действие при конфликте
+задается последующим вызовом DoNothing или DoUpdate. В MySQL колонки конфликта
+не указываются в запросе: конфликт определяется любым уникальным ключом таблицы.
+Параметры:
+	columns - колонки ограничения уникальности
+Результат:
+	ссылка Selector на самого себя
+Пример использования:
+	selector := &Selector{}
+	selector.Insert("user").Values(users).OnConflict("email").DoUpdate("name")
+	тогда sql содержит: INSERT INTO "user" (...) VALUES (...) ON CONFLICT ("email") DO UPDATE SET "name" = EXCLUDED."name"
+	а для MySQL: INSERT INTO `user` (...) VALUES (...) ON DUPLICATE KEY UPDATE `name` = VALUES(`name`)
+*/
+func (s *Selector) OnConflict(columns ...string) *Selector {
+	s.conflict = &onConflict{columns: columns}
+	return s
+}
+
+/*Задает пропуск конфликтующих строк: ON CONFLICT DO NOTHING. В MySQL
+формируется ON DUPLICATE KEY UPDATE с присваиванием колонке ее же значения.
+Результат:
+	ссылка Selector на самого себя
+Пример использования:
+	selector := &Selector{}
+	selector.Insert("user").Values(users).OnConflict("email").DoNothing()
+*/
+func (s *Selector) DoNothing() *Selector {
+	if s.conflict == nil {
+		s.setError(ErrNoConflict)
+		return s
+	}
+	s.conflict.doNothing = true
+	return s
+}
+
+/*Задает обновление конфликтующих строк значениями из вставляемой строки.
+Если колонки не указаны, обновляются все вставляемые колонки, кроме колонок конфликта.
+Параметры:
+	columns - обновляемые колонки
+Результат:
+	ссылка Selector на самого себя
+Пример использования:
+	selector := &Selector{}
+	selector.Insert("user").Values(users).OnConflict("email").DoUpdate("name", "updated_at")
+*/
+func (s *Selector) DoUpdate(columns ...string) *Selector {
+	if s.conflict == nil {
+		s.setError(ErrNoConflict)
+		return s
+	}
+	s.conflict.doUpdate = true
+	s.conflict.updateAll = s.conflict.updateAll || len(columns) == 0
+	for _, column := range columns {
+		s.conflict.sets = append(s.conflict.sets, setItem{field: column, bind: excludedValue{}})
+	}
+	return s
+}
+
+/*Задает обновление колонки конфликтующей строки произвольным значением,
+для ссылки на другую колонку значение нужно передать как Ident.
+Параметры:
+	field - обновляемая колонка
+	bind - данные для подстановки или Ident
+Результат:
+	ссылка Selector на самого себя
+Пример использования:
+	selector := &Selector{}
+	selector.Insert("counter").Values(counters).OnConflict("name").DoUpdate("value").DoUpdateSet("updated", true)
+*/
+func (s *Selector) DoUpdateSet(field string, bind interface{}) *Selector {
+	if s.conflict == nil {
+		s.setError(ErrNoConflict)
+		return s
+	}
+	s.conflict.doUpdate = true
+	s.conflict.sets = append(s.conflict.sets, setItem{field: field, bind: bind})
+	return s
+}
+
+//формирует секцию ON CONFLICT, inserted - имена вставляемых колонок
+func (s *Selector) conflictSql(inserted []string, raw bool, binds map[string]interface{}) (string, error) {
+	c := s.conflict
+	if c == nil {
+		return "", nil
+	}
+	if c.doNothing == c.doUpdate {
+		return "", fmt.Errorf("%w: нужно задать одно из действий DoNothing или DoUpdate", ErrConflictClause)
+	}
+
+	sets := c.sets
+	if c.updateAll {
+		sets = make([]setItem, 0, len(inserted)+len(c.sets))
+		for _, column := range inserted {
+			if !containsString(c.columns, column) {
+				sets = append(sets, setItem{field: column, bind: excludedValue{}})
+			}
+		}
+		sets = append(sets, c.sets...)
+	}
+
+	if s.dialect == DIALECT_MYSQL {
+		if c.doNothing {
+			if len(inserted) == 0 {
+				return "", fmt.Errorf("%w: нет вставляемых колонок", ErrConflictClause)
+			}
+			column := inserted[0]
+			if len(c.columns) > 0 {
+				column = c.columns[0]
+			}
+			sets = []setItem{{field: column, bind: Ident(column)}}
+		}
+		setSql, err := s.conflictSetSql(sets, raw, binds)
+		if err != nil {
+			return "", err
+		}
+		return " ON DUPLICATE KEY UPDATE " + setSql, nil
+	}
+
+	resultSql := " ON CONFLICT"
+	if len(c.columns) > 0 {
+		columns, err := s.identifierList(c.columns)
+		if err != nil {
+			return "", err
+		}
+		resultSql += " (" + columns + ")"
+	} else if c.doUpdate {
+		return "", fmt.Errorf("%w: для DO UPDATE нужно указать колонки конфликта", ErrConflictClause)
+	}
+
+	if c.doNothing {
+		return resultSql + " DO NOTHING", nil
+	}
+	setSql, err := s.conflictSetSql(sets, raw, binds)
+	if err != nil {
+		return "", err
+	}
+	return resultSql + " DO UPDATE SET " + setSql, nil
+}
+
+//формирует список присваиваний для DO UPDATE SET или ON DUPLICATE KEY UPDATE
+func (s *Selector) conflictSetSql(sets []setItem, raw bool, binds map[string]interface{}) (string, error) {
+	if len(sets) == 0 {
+		return "", fmt.Errorf("%w: нет обновляемых колонок", ErrConflictClause)
+	}
+
+	parts := make([]string, 0, len(sets))
+	for _, si := range sets {
+		field, err := s.identifier(si.field)
+		if err != nil {
+			return "", err
+		}
+		value := ""
+		if _, ok := si.bind.(excludedValue); ok {
+			if s.dialect == DIALECT_MYSQL {
+				value = "VALUES(" + field + ")"
+			} else {
+				value = "EXCLUDED." + field
+			}
+		} else if value, err = s.bindValue(si.field, si.bind, raw, binds); err != nil {
+			return "", err
+		}
+		parts = append(parts, field+" = "+value)
+	}
+	return strings.Join(parts, ", "), nil
+}
+
+//возвращает true, если срез содержит строку
+func containsString(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
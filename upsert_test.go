@@ -0,0 +1,93 @@
+package dbselector
+
+import "testing"
+
+type upsertStruct struct {
+	Id    int64  `db:"id,pk,auto"`
+	Email string `db:"email"`
+	Name  string `db:"name"`
+	Hits  int    `db:"hits"`
+}
+
+func TestUpsertDoNothing(t *testing.T) {
+
+	item := upsertStruct{Email: "vova@fulleren.io", Name: "Vova", Hits: 1}
+
+	sel := &Selector{}
+	sel.Insert("user").Values(item).OnConflict("email").DoNothing()
+	sql, _, err := sel.BuildRaw()
+	compareError(t, nil, err)
+	compareSql(t, "INSERT INTO \"user\" (\"email\", \"name\", \"hits\") VALUES ($1, $2, $3) "+
+		"ON CONFLICT (\"email\") DO NOTHING", sql)
+
+	sel = &Selector{}
+	sel.Insert("user").Values(item).OnConflict().DoNothing()
+	sql, _, err = sel.BuildRaw()
+	compareError(t, nil, err)
+	compareSql(t, "INSERT INTO \"user\" (\"email\", \"name\", \"hits\") VALUES ($1, $2, $3) ON CONFLICT DO NOTHING", sql)
+
+	sel = NewSelector(DIALECT_MYSQL)
+	sel.Insert("user").Values(item).OnConflict("email").DoNothing()
+	sql, _, err = sel.BuildRaw()
+	compareError(t, nil, err)
+	compareSql(t, "INSERT INTO `user` (`email`, `name`, `hits`) VALUES (?, ?, ?) "+
+		"ON DUPLICATE KEY UPDATE `email` = `email`", sql)
+}
+
+func TestUpsertDoUpdate(t *testing.T) {
+
+	item := upsertStruct{Email: "vova@fulleren.io", Name: "Vova", Hits: 1}
+
+	sel := &Selector{}
+	sel.Insert("user").Values(item).OnConflict("email").DoUpdate("name").DoUpdateSet("hits", 0).Returning("id")
+	sql, binds, err := sel.BuildRaw()
+	compareError(t, nil, err)
+	compareSql(t, "INSERT INTO \"user\" (\"email\", \"name\", \"hits\") VALUES ($1, $2, $3) "+
+		"ON CONFLICT (\"email\") DO UPDATE SET \"name\" = EXCLUDED.\"name\", \"hits\" = $4 RETURNING \"id\"", sql)
+	compareBinds(t, binds, []interface{}{"vova@fulleren.io", "Vova", 1, 0})
+
+	sel = NewSelector(DIALECT_SQLITE)
+	sel.Insert("user").Values(item).OnConflict("email").DoUpdate()
+	sql, _, err = sel.BuildRaw()
+	compareError(t, nil, err)
+	compareSql(t, "INSERT INTO \"user\" (\"email\", \"name\", \"hits\") VALUES (?, ?, ?) "+
+		"ON CONFLICT (\"email\") DO UPDATE SET \"name\" = EXCLUDED.\"name\", \"hits\" = EXCLUDED.\"hits\"", sql)
+
+	sel = NewSelector(DIALECT_MYSQL)
+	sel.Insert("user").Values(item).OnConflict("email").DoUpdate("name", "hits")
+	sql, _, err = sel.BuildRaw()
+	compareError(t, nil, err)
+	compareSql(t, "INSERT INTO `user` (`email`, `name`, `hits`) VALUES (?, ?, ?) "+
+		"ON DUPLICATE KEY UPDATE `name` = VALUES(`name`), `hits` = VALUES(`hits`)", sql)
+}
+
+func TestUpsertErrors(t *testing.T) {
+
+	item := upsertStruct{Email: "vova@fulleren.io"}
+
+	sel := &Selector{}
+	sel.Insert("user").Values(item).DoNothing()
+	_, _, err := sel.Build()
+	compareError(t, ErrNoConflict, err)
+
+	sel = &Selector{}
+	sel.Insert("user").Values(item).OnConflict("email")
+	_, _, err = sel.Build()
+	compareError(t, ErrConflictClause, err)
+
+	sel = &Selector{}
+	sel.Insert("user").Values(item).OnConflict().DoUpdate("name")
+	_, _, err = sel.Build()
+	compareError(t, ErrConflictClause, err)
+
+	//upsert с ошибкой не превращается в обычный INSERT
+	sel = &Selector{}
+	sel.Insert("user").Values(struct {
+		Email string `db:"email"`
+	}{"vova@fulleren.io"}).OnConflict("email").DoUpdate()
+	sql, binds := sel.Sql()
+	compareSql(t, "", sql)
+	compareBinds(t, binds, map[string]interface{}{})
+	_, _, err = sel.Build()
+	compareError(t, ErrConflictClause, err)
+}
@@ -0,0 +1,271 @@
+package dbselector
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// вызов оконной функции: row_number(), ntile(4), lag(price, 1), first_value(price)
+var windowFunctionRegexp = regexp.MustCompile(`(?i)^\s*(row_number|rank|dense_rank|percent_rank|cume_dist|ntile|lag|lead|first_value|last_value|nth_value)\s*\(\s*([^()]*?)\s*\)\s*$`)
+
+// граница рамки окна: ROWS BETWEEN UNBOUNDED PRECEDING AND CURRENT ROW
+var (
+	frameBound  = `(UNBOUNDED PRECEDING|UNBOUNDED FOLLOWING|CURRENT ROW|[0-9]+ PRECEDING|[0-9]+ FOLLOWING)`
+	frameRegexp = regexp.MustCompile(`^(ROWS|RANGE|GROUPS) (BETWEEN ` + frameBound + ` AND ` + frameBound + `|` + frameBound + `)$`)
+)
+
+// целочисленный аргумент оконной функции
+var integerRegexp = regexp.MustCompile(`^[0-9]+$`)
+
+/*Window - описание окна оконной функции: разбиение, сортировка и рамка.
+Окно передается в ColumnOver или объявляется в секции WINDOW через Selector.Window,
+тогда на него можно ссылаться по имени через NamedWindow.
+Пример использования:
+	w := NewWindow().PartitionBy("user_id").OrderBy("created", "desc")
+	selector := &Selector{}
+	selector.Select("order").Columns("id").ColumnOver("row_number()", w, "num")
+	тогда sql содержит: SELECT "id", row_number() OVER (PARTITION BY "user_id" ORDER BY "created" desc) AS "num" FROM "order"
+*/
+type Window struct {
+	name      string   //имя окна из секции WINDOW, на котором основано это окно
+	partition []string //поля секции PARTITION BY
+	orders    []order  //поля секции ORDER BY
+	frame     string   //рамка окна: ROWS, RANGE или GROUPS
+	err       error    //первая ошибка, допущенная при описании окна
+}
+
+// именованное окно секции WINDOW
+type namedWindow struct {
+	name   string
+	window *Window
+}
+
+// Создает пустое окно, т.е. окно из всех строк результата
+func NewWindow() *Window {
+	return &Window{}
+}
+
+/*Создает ссылку на окно, объявленное в секции WINDOW. Ссылку можно дополнить
+сортировкой и рамкой, если они не заданы в самом именованном окне.
+Пример использования:
+	selector := &Selector{}
+	selector.Select("order").Window("w", NewWindow().PartitionBy("user_id")).
+		ColumnOver("sum(amount)", NamedWindow("w").OrderBy("created", "asc"), "total")
+	тогда sql содержит: SELECT sum("amount") OVER ("w" ORDER BY "created" asc) AS "total" FROM "order"
+		WINDOW "w" AS (PARTITION BY "user_id")
+*/
+func NamedWindow(name string) *Window {
+	return &Window{name: name}
+}
+
+// Добавляет поля в секцию PARTITION BY окна
+func (w *Window) PartitionBy(fields ...string) *Window {
+	w.partition = append(w.partition, fields...)
+	return w
+}
+
+/*Добавляет поле в секцию ORDER BY окна. Поле и направление сортировки проверяются
+так же, как в OrderBind, в том числе по списку SortableColumns селектора.
+Параметры:
+	field - имя поля для сортировки
+	dir - направление сортировки ASC или DESC, возможно с NULLS FIRST или NULLS LAST
+Результат:
+	ссылка Window на самого себя
+*/
+func (w *Window) OrderBy(field string, dir string) *Window {
+	o, err := newOrder(field, dir)
+	if err != nil && w.err == nil {
+		w.err = err
+	}
+	w.orders = append(w.orders, o)
+	return w
+}
+
+/*Задает рамку окна. Допускаются только рамки вида
+	ROWS|RANGE|GROUPS граница
+	ROWS|RANGE|GROUPS BETWEEN граница AND граница
+где граница - UNBOUNDED PRECEDING, UNBOUNDED FOLLOWING, CURRENT ROW, N PRECEDING или N FOLLOWING.
+Пример использования:
+	w := NewWindow().OrderBy("created", "asc").Frame("ROWS BETWEEN 6 PRECEDING AND CURRENT ROW")
+*/
+func (w *Window) Frame(frame string) *Window {
+	w.frame = frame
+	return w
+}
+
+/*Добавляет к списку выбираемых полей оконную функцию function OVER (окно).
+В качестве функции допускаются row_number(), rank(), dense_rank(), percent_rank(),
+cume_dist(), ntile(N), lag и lead (поле[, N]), first_value, last_value (поле), nth_value(поле, N),
+а также агрегатные функции count, sum, avg, min, max над полем.
+Параметры:
+	function - вызов оконной функции
+	window - окно
+	alias - псевдоним, если пустая строка - псевдоним не указывается
+Результат:
+	ссылка Selector на самого себя
+Пример использования:
+	selector := &Selector{}
+	selector.Select("score").Columns("user_id").ColumnOver("rank()", NewWindow().OrderBy("points", "desc"), "place")
+	тогда sql содержит: SELECT "user_id", rank() OVER (ORDER BY "points" desc) AS "place" FROM "score"
+*/
+func (s *Selector) ColumnOver(function string, window *Window, alias string) *Selector {
+	if window == nil {
+		s.setError(fmt.Errorf("%w: не задано окно для %q", ErrInvalidWindow, function))
+		return s
+	}
+	s.columns = append(s.columns, column{field: function, window: window, alias: alias})
+	return s
+}
+
+/*Объявляет именованное окно в секции WINDOW запроса SELECT
+Параметры:
+	name - имя окна
+	window - описание окна
+Результат:
+	ссылка Selector на самого себя
+Пример использования:
+	selector := &Selector{}
+	selector.Select("order").Window("w", NewWindow().PartitionBy("user_id").OrderBy("created", "asc")).
+		ColumnOver("row_number()", NamedWindow("w"), "num").ColumnOver("sum(amount)", NamedWindow("w"), "total")
+	тогда sql содержит: SELECT row_number() OVER "w" AS "num", sum("amount") OVER "w" AS "total" FROM "order"
+		WINDOW "w" AS (PARTITION BY "user_id" ORDER BY "created" asc)
+*/
+func (s *Selector) Window(name string, window *Window) *Selector {
+	s.windows = append(s.windows, namedWindow{name: name, window: window})
+	return s
+}
+
+//формирует секцию WINDOW запроса
+func (s *Selector) windowsSql() (string, error) {
+	if len(s.windows) == 0 {
+		return "", nil
+	}
+
+	items := make([]string, 0, len(s.windows))
+	for _, nw := range s.windows {
+		name, err := s.identifier(nw.name)
+		if err != nil {
+			return "", err
+		}
+		spec, err := s.windowSpecSql(nw.window)
+		if err != nil {
+			return "", err
+		}
+		items = append(items, name+" AS ("+spec+")")
+	}
+	return " WINDOW " + strings.Join(items, ", "), nil
+}
+
+//формирует вызов оконной функции с секцией OVER
+func (s *Selector) windowColumnSql(function string, window *Window) (string, error) {
+	functionSql, err := s.windowFunctionSql(function)
+	if err != nil {
+		return "", err
+	}
+
+	// ссылка на именованное окно без дополнений записывается без скобок
+	if window.name != "" && len(window.partition) == 0 && len(window.orders) == 0 && window.frame == "" {
+		name, err := s.identifier(window.name)
+		if err != nil {
+			return "", err
+		}
+		return functionSql + " OVER " + name, nil
+	}
+	spec, err := s.windowSpecSql(window)
+	if err != nil {
+		return "", err
+	}
+	return functionSql + " OVER (" + spec + ")", nil
+}
+
+//возвращает вызов оконной или агрегатной функции с именами полей в кавычках
+func (s *Selector) windowFunctionSql(function string) (string, error) {
+	if sql, ok, err := s.aggregateSql(function); ok || err != nil {
+		return sql, err
+	}
+
+	m := windowFunctionRegexp.FindStringSubmatch(function)
+	if m == nil {
+		return "", fmt.Errorf("%w: функция %q", ErrInvalidWindow, function)
+	}
+	name := strings.ToLower(m[1])
+	args := make([]string, 0, 2)
+	if m[2] != "" {
+		args = strings.Split(m[2], ",")
+	}
+
+	// допустимое количество аргументов и номер первого целочисленного аргумента
+	minArgs, maxArgs, firstInt := 0, 0, 0
+	switch name {
+	case "ntile":
+		minArgs, maxArgs, firstInt = 1, 1, 0
+	case "lag", "lead":
+		minArgs, maxArgs, firstInt = 1, 2, 1
+	case "first_value", "last_value":
+		minArgs, maxArgs, firstInt = 1, 1, 1
+	case "nth_value":
+		minArgs, maxArgs, firstInt = 2, 2, 1
+	}
+	if len(args) < minArgs || len(args) > maxArgs {
+		return "", fmt.Errorf("%w: функция %q", ErrInvalidWindow, function)
+	}
+
+	for i := range args {
+		arg := strings.TrimSpace(args[i])
+		if i >= firstInt {
+			if !integerRegexp.MatchString(arg) {
+				return "", fmt.Errorf("%w: функция %q", ErrInvalidWindow, function)
+			}
+			args[i] = arg
+			continue
+		}
+		quoted, err := s.identifier(arg)
+		if err != nil {
+			return "", err
+		}
+		args[i] = quoted
+	}
+	return name + "(" + strings.Join(args, ", ") + ")", nil
+}
+
+//формирует описание окна без внешних скобок
+func (s *Selector) windowSpecSql(window *Window) (string, error) {
+	if window == nil {
+		return "", fmt.Errorf("%w: окно не задано", ErrInvalidWindow)
+	}
+	if window.err != nil {
+		return "", window.err
+	}
+
+	parts := make([]string, 0, 4)
+	if window.name != "" {
+		name, err := s.identifier(window.name)
+		if err != nil {
+			return "", err
+		}
+		parts = append(parts, name)
+	}
+	if len(window.partition) > 0 {
+		fields, err := s.identifierList(window.partition)
+		if err != nil {
+			return "", err
+		}
+		parts = append(parts, "PARTITION BY "+fields)
+	}
+	if len(window.orders) > 0 {
+		orders, err := s.ordersSql(window.orders)
+		if err != nil {
+			return "", err
+		}
+		parts = append(parts, "ORDER BY "+orders)
+	}
+	if window.frame != "" {
+		frame := strings.ToUpper(strings.Join(strings.Fields(window.frame), " "))
+		if !frameRegexp.MatchString(frame) {
+			return "", fmt.Errorf("%w: рамка %q", ErrInvalidWindow, window.frame)
+		}
+		parts = append(parts, frame)
+	}
+	return strings.Join(parts, " "), nil
+}
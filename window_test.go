@@ -0,0 +1,73 @@
+package dbselector
+
+import "testing"
+
+func TestColumnOver(t *testing.T) {
+
+	w := NewWindow().PartitionBy("user_id").OrderBy("created", "desc")
+
+	sel := &Selector{}
+	sel.Select("order").Columns("id").ColumnOver("row_number()", w, "num").
+		ColumnOver("lag(amount, 1)", NewWindow().OrderBy("created", "asc"), "prev").
+		ColumnOver("sum(amount)", NewWindow().OrderBy("created", "asc").Frame("rows between 6 preceding and current row"), "")
+	sql, _, err := sel.Build()
+	compareError(t, nil, err)
+
+	gageSql := "SELECT \"id\", row_number() OVER (PARTITION BY \"user_id\" ORDER BY \"created\" desc) AS \"num\", " +
+		"lag(\"amount\", 1) OVER (ORDER BY \"created\" asc) AS \"prev\", " +
+		"sum(\"amount\") OVER (ORDER BY \"created\" asc ROWS BETWEEN 6 PRECEDING AND CURRENT ROW) FROM \"order\""
+	compareSql(t, gageSql, sql)
+}
+
+func TestNamedWindow(t *testing.T) {
+
+	sel := NewSelector(DIALECT_MYSQL)
+	sel.Select("order").Window("w", NewWindow().PartitionBy("user_id")).
+		ColumnOver("rank()", NamedWindow("w"), "place").
+		ColumnOver("sum(amount)", NamedWindow("w").OrderBy("created", "asc"), "total").
+		Where("amount", ">", 0).OrderBind("user_id", "asc")
+	sql, binds, err := sel.BuildRaw()
+	compareError(t, nil, err)
+
+	gageSql := "SELECT rank() OVER `w` AS `place`, sum(`amount`) OVER (`w` ORDER BY `created` asc) AS `total` " +
+		"FROM `order` WHERE `amount` > ? WINDOW `w` AS (PARTITION BY `user_id`) ORDER BY `user_id` asc"
+	compareSql(t, gageSql, sql)
+	compareBinds(t, binds, []interface{}{0})
+}
+
+func TestWindowErrors(t *testing.T) {
+
+	cases := []struct {
+		name     string
+		function string
+		window   *Window
+		err      error
+	}{
+		{"unknown function", "pg_sleep(10)", NewWindow(), ErrInvalidWindow},
+		{"ntile column", "ntile(amount)", NewWindow(), ErrInvalidWindow},
+		{"lag arguments", "lag(amount, 1, 2)", NewWindow(), ErrInvalidWindow},
+		{"lag column", "lag(amount; drop)", NewWindow(), ErrInvalidIdentifier},
+		{"frame", "rank()", NewWindow().Frame("rows 1; drop table user"), ErrInvalidWindow},
+		{"no window", "rank()", nil, ErrInvalidWindow},
+		{"sortable", "rank()", NewWindow().OrderBy("password", "asc"), ErrColumnNotAllowed},
+	}
+
+	for _, c := range cases {
+		sel := &Selector{}
+		sel.Select("user").SortableColumns("name").ColumnOver(c.function, c.window, "")
+		_, _, err := sel.Build()
+		if err == nil {
+			t.Errorf("%s: no error", c.name)
+			continue
+		}
+		compareError(t, c.err, err)
+	}
+}
+
+func TestWindowInvalidOrder(t *testing.T) {
+
+	sel := &Selector{}
+	sel.Select("user").ColumnOver("rank()", NewWindow().OrderBy("points", "desc nulls"), "place")
+	_, _, err := sel.Build()
+	compareError(t, ErrInvalidOrder, err)
+}